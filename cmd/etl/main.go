@@ -0,0 +1,253 @@
+// etl 命令实现 pipeline_pattern.go 扩展练习1的文件处理Pipeline：
+// 读取文件、过滤无效行、用配置的阶段转换数据、原子地写入新文件
+//
+// 输入输出格式由扩展名决定：.csv 为CSV，.jsonl / .ndjson 为JSON Lines，其他按行处理。
+//
+// 用法：
+//
+//	go run ./cmd/etl -in cmd/etl/testdata/numbers.txt -out /tmp/out.jsonl -config cmd/pipeline/example.yaml
+//	go run ./cmd/etl -in cmd/etl/testdata/scores.csv -column score -out /tmp/out.csv
+//	go run ./cmd/etl -in cmd/etl/testdata/events.jsonl -field value -out /tmp/out.txt
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/pipeline"
+)
+
+func main() {
+	inPath := flag.String("in", "", "输入文件")
+	outPath := flag.String("out", "", "输出文件，写完后才会替换已有文件")
+	configPath := flag.String("config", "", "转换阶段的Pipeline配置（可选，不指定时原样输出）")
+	columnName := flag.String("column", "", "CSV中数值所在列的表头名，默认第一列")
+	field := flag.String("field", "value", "JSON Lines中数值所在的字段")
+	mode := flag.String("errors", "skip", "无效记录的处理方式: fail / skip")
+	flag.Parse()
+
+	if *inPath == "" || *outPath == "" {
+		fmt.Fprintln(os.Stderr, "请使用 -in 和 -out 指定输入输出文件")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var errorMode pipeline.ErrorMode
+	switch *mode {
+	case "fail":
+		errorMode = pipeline.FailFast
+	case "skip":
+		errorMode = pipeline.SkipAndCollect
+	default:
+		fmt.Fprintf(os.Stderr, "未知的错误处理方式 %q, 可选 fail / skip\n", *mode)
+		os.Exit(2)
+	}
+
+	var transform *pipeline.Configured[int]
+	if *configPath != "" {
+		cfg, err := pipeline.LoadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+			os.Exit(1)
+		}
+		if transform, err = pipeline.Build(cfg, pipeline.IntRegistry()); err != nil {
+			fmt.Fprintf(os.Stderr, "配置校验失败:\n%v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// CSV的列名在启动Pipeline之前检查，列不存在时不应写出任何东西
+	column := 0
+	if format(*inPath) == "csv" {
+		var err error
+		if column, err = csvColumnIndex(*inPath, *columnName); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, policy := pipeline.NewErrorPolicy(ctx, errorMode)
+	defer policy.Stop()
+
+	// 提取：读取原始字段
+	var raw <-chan pipeline.Result[string]
+	switch format(*inPath) {
+	case "csv":
+		raw = csvColumn(ctx, pipeline.ReadCSV(ctx, *inPath), column)
+	case "jsonl":
+		raw = pipeline.TryMap(ctx, "提取", pipeline.ReadJSONLines[map[string]json.RawMessage](ctx, *inPath), func(obj map[string]json.RawMessage) (string, error) {
+			v, ok := obj[*field]
+			if !ok {
+				return "", fmt.Errorf("缺少字段 %q", *field)
+			}
+			return jsonText(v), nil
+		})
+	default:
+		raw = pipeline.ReadLines(ctx, *inPath)
+	}
+
+	// 过滤：空行直接丢弃，无法解析为整数的记录按错误处理方式跳过或中止
+	raw = pipeline.Filter(ctx, raw, func(r pipeline.Result[string]) bool {
+		return r.Err != nil || strings.TrimSpace(r.Value) != ""
+	})
+	numbers := pipeline.Handle(ctx, policy, pipeline.TryMap(ctx, "解析", raw, func(s string) (int, error) {
+		return strconv.Atoi(strings.TrimSpace(s))
+	}))
+
+	// 转换
+	if transform != nil {
+		numbers = pipeline.Handle(ctx, policy, transform.Run(ctx, numbers))
+	}
+	policy.Close()
+
+	// 加载：按输出格式写入
+	var (
+		n   int
+		err error
+	)
+	switch format(*outPath) {
+	case "csv":
+		records := pipeline.Map(ctx, numbers, func(v int) []string { return []string{strconv.Itoa(v)} })
+		n, err = pipeline.WriteCSV(ctx, *outPath, prepend(ctx, []string{"value"}, records))
+		n = max(n-1, 0) // 表头不算记录
+	case "jsonl":
+		type row struct {
+			Value int `json:"value"`
+		}
+		n, err = pipeline.WriteJSONLines(ctx, *outPath, pipeline.Map(ctx, numbers, func(v int) row { return row{Value: v} }))
+	default:
+		n, err = pipeline.WriteLines(ctx, *outPath, pipeline.Map(ctx, numbers, strconv.Itoa))
+	}
+
+	report := policy.Report()
+	if report.Failed() > 0 {
+		fmt.Fprintf(os.Stderr, "无效记录 %d 条:\n%v\n", report.Failed(), report.Err())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "写入 %s 失败, 目标文件未修改: %v\n", *outPath, err)
+		os.Exit(1)
+	}
+	fmt.Printf("写入 %s: %d 条记录\n", *outPath, n)
+}
+
+// format 根据扩展名判断文件格式
+func format(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return "csv"
+	case ".jsonl", ".ndjson":
+		return "jsonl"
+	default:
+		return "lines"
+	}
+}
+
+// jsonText 返回JSON值的文本：字符串去掉引号，其他值保持原文
+// 数字不经过float64，1000000 不会变成 1e+06
+func jsonText(v json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(v, &s); err == nil {
+		return s
+	}
+	return string(v)
+}
+
+// csvColumnIndex 读取CSV的表头，返回列名所在的位置
+func csvColumnIndex(path, column string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		return 0, fmt.Errorf("读取 %s 的表头失败: %w", path, err)
+	}
+	idx := headerIndex(header, column)
+	if idx < 0 {
+		return 0, fmt.Errorf("表头 %v 中没有列 %q", header, column)
+	}
+	return idx, nil
+}
+
+// csvColumn 跳过第一条记录（表头），输出之后每条记录中第idx列的值
+func csvColumn(ctx context.Context, in <-chan pipeline.Result[[]string], idx int) <-chan pipeline.Result[string] {
+	out := make(chan pipeline.Result[string])
+
+	go func() {
+		defer close(out)
+
+		header := true
+		for r := range in {
+			var next pipeline.Result[string]
+			switch {
+			case r.Err != nil:
+				next.Err = r.Err
+			case header:
+				header = false
+				continue
+			case idx >= len(r.Value):
+				next.Err = fmt.Errorf("记录 %v 缺少第%d列", r.Value, idx+1)
+			default:
+				next = pipeline.Ok(r.Value[idx])
+			}
+
+			select {
+			case out <- next:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// headerIndex 返回列名在表头中的位置，列名为空时返回0，找不到时返回-1
+func headerIndex(header []string, column string) int {
+	if column == "" {
+		return 0
+	}
+	for i, name := range header {
+		if strings.TrimSpace(name) == column {
+			return i
+		}
+	}
+	return -1
+}
+
+// prepend 先输出first，再转发in中的元素
+func prepend[T any](ctx context.Context, first T, in <-chan T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		select {
+		case out <- first:
+		case <-ctx.Done():
+			return
+		}
+		for v := range in {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
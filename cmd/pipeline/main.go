@@ -0,0 +1,141 @@
+// pipeline 命令根据配置文件构建并运行整数处理Pipeline
+//
+// 用法：
+//
+//	go run ./cmd/pipeline -config cmd/pipeline/example.yaml -validate
+//	go run ./cmd/pipeline -config cmd/pipeline/example.yaml -from 1 -to 10
+//	go run ./cmd/pipeline -config cmd/pipeline/example.yaml -to 100000 -monitor 500ms > /dev/null
+//	go run ./cmd/pipeline -stages
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/pipeline"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Pipeline配置文件（.json / .yaml）")
+	validateOnly := flag.Bool("validate", false, "只校验配置，不运行")
+	listStages := flag.Bool("stages", false, "列出所有可用的阶段")
+	from := flag.Int("from", 1, "输入数据的起始值")
+	to := flag.Int("to", 10, "输入数据的结束值（包含）")
+	mode := flag.String("errors", "skip", "错误处理方式: fail / skip / dead")
+	refresh := flag.Duration("monitor", 0, "大于0时在标准错误上按此间隔刷新各阶段的监控面板")
+	flag.Parse()
+
+	reg := pipeline.IntRegistry()
+
+	if *listStages {
+		fmt.Println("可用的阶段:")
+		for _, name := range reg.Names() {
+			fmt.Printf("  %s\n", name)
+		}
+		return
+	}
+
+	if *configPath == "" {
+		fmt.Fprintln(os.Stderr, "请使用 -config 指定配置文件")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := pipeline.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	p, err := pipeline.Build(cfg, reg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "配置校验失败:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	names := make([]string, len(cfg.Stages))
+	for i, s := range cfg.Stages {
+		names[i] = s.Stage
+	}
+	fmt.Printf("Pipeline %q: %s\n", cfg.Name, strings.Join(names, " → "))
+	if *validateOnly {
+		fmt.Println("配置校验通过")
+		return
+	}
+
+	errorMode, err := parseErrorMode(*mode)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, policy := pipeline.NewErrorPolicy(ctx, errorMode)
+	defer policy.Stop()
+
+	var monitor *pipeline.Monitor
+	dashDone := make(chan struct{})
+	dashCtx, stopDash := context.WithCancel(ctx)
+	if *refresh > 0 {
+		monitor = pipeline.NewMonitor()
+		p.WithMonitor(monitor)
+		go func() {
+			defer close(dashDone)
+			monitor.Dashboard(dashCtx, os.Stderr, *refresh)
+		}()
+	} else {
+		close(dashDone)
+	}
+
+	out := pipeline.Handle(ctx, policy, p.Run(ctx, pipeline.Generate(ctx, *from, *to)))
+	policy.Close()
+
+	deadDone := make(chan struct{})
+	go func() {
+		defer close(deadDone)
+		for err := range policy.DeadLetters() {
+			fmt.Fprintf(os.Stderr, "死信: %v\n", err)
+		}
+	}()
+
+	count := 0
+	for v := range out {
+		fmt.Printf("结果: %d\n", v)
+		count++
+	}
+	// Handle 结束后死信Channel随之关闭，等最后几条打印完
+	<-deadDone
+
+	stopDash()
+	<-dashDone
+	if monitor != nil {
+		fmt.Fprintln(os.Stderr, "\n最终统计:")
+		monitor.WriteTable(os.Stderr)
+	}
+
+	report := policy.Report()
+	fmt.Printf("完成: 输出 %d 个结果, %d 个错误\n", count, report.Failed())
+	if err := report.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "错误汇总:\n%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseErrorMode 解析 -errors 参数
+func parseErrorMode(s string) (pipeline.ErrorMode, error) {
+	switch s {
+	case "fail":
+		return pipeline.FailFast, nil
+	case "skip":
+		return pipeline.SkipAndCollect, nil
+	case "dead":
+		return pipeline.DeadLetter, nil
+	default:
+		return 0, fmt.Errorf("未知的错误处理方式 %q, 可选 fail / skip / dead", s)
+	}
+}
@@ -4,4 +4,5 @@ go 1.22
 
 require (
 	github.com/stretchr/testify v1.9.0
-)
\ No newline at end of file
+	gopkg.in/yaml.v3 v3.0.1
+)
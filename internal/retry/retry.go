@@ -0,0 +1,92 @@
+// Package retry 是 workerpool 和 pipeline 共用的重试策略：执行次数、指数退避和抖动。
+//
+// 重试循环参考练习2的超时重试：每次失败后用 select 等待退避时间，
+// 等待期间context被取消则立即放弃。
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// Policy 描述失败后的重试方式，零值表示不重试
+type Policy struct {
+	// MaxAttempts 最多执行次数（包含第一次），小于1时按1处理
+	MaxAttempts int
+	// InitialBackoff 第一次重试前的等待时间
+	InitialBackoff time.Duration
+	// MaxBackoff 退避时间上限，0表示不限制
+	MaxBackoff time.Duration
+	// Multiplier 每次重试后退避时间的倍数，小于1时按2处理
+	Multiplier float64
+	// Jitter 随机抖动比例，取值[0,1]，例如0.2表示在退避时间上下浮动20%
+	Jitter float64
+	// Retryable 判断错误是否值得重试，nil表示所有错误都重试
+	Retryable func(error) bool
+}
+
+// Attempts 返回最多执行次数
+func (p Policy) Attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// ShouldRetry 判断err是否值得重试
+func (p Policy) ShouldRetry(err error) bool {
+	if p.Retryable == nil {
+		return true
+	}
+	return p.Retryable(err)
+}
+
+// Backoff 计算第n次重试前的等待时间（n从1开始）
+func (p Policy) Backoff(n int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	d := float64(p.InitialBackoff)
+	for i := 1; i < n; i++ {
+		d *= multiplier
+		if p.MaxBackoff > 0 && d >= float64(p.MaxBackoff) {
+			d = float64(p.MaxBackoff)
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		jitter := p.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+		// 在 [1-jitter, 1+jitter] 范围内随机缩放
+		d *= 1 - jitter + 2*jitter*rand.Float64()
+	}
+
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	return time.Duration(d)
+}
+
+// Sleep 等待d，ctx取消时提前返回false
+// 用 time.NewTimer 而不是 time.After，取消时立即停止计时器，不必等到退避时间结束才释放
+func Sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
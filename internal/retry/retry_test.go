@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestBackoffGrowth 测试指数退避和上限
+func TestBackoffGrowth(t *testing.T) {
+	p := Policy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 50 * time.Millisecond},
+		{10, 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := p.Backoff(tt.n); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, 期望 %v", tt.n, got, tt.want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		d := p.Backoff(2)
+		if d < 10*time.Millisecond || d > 30*time.Millisecond {
+			t.Fatalf("带抖动的 Backoff(2) = %v 超出 [10ms, 30ms]", d)
+		}
+	}
+}
+
+// TestSleepCancelled 测试取消时 Sleep 立即返回false
+func TestSleepCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if Sleep(ctx, time.Hour) {
+		t.Error("取消后 Sleep 应返回false")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后等待时间过长: %v", elapsed)
+	}
+	if !Sleep(context.Background(), time.Millisecond) {
+		t.Error("未取消时 Sleep 应返回true")
+	}
+}
@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// ErrCircuitOpen 在熔断器打开、调用被绕过时记录到失败历史中
+var ErrCircuitOpen = errors.New("pipeline: circuit breaker is open")
+
+// BreakerState 是熔断器的状态
+type BreakerState int
+
+const (
+	// BreakerClosed 正常放行所有调用
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 失败率过高，所有调用被绕过，直到冷却时间结束
+	BreakerOpen
+	// BreakerHalfOpen 冷却结束后只放行一次试探调用，成功则关闭，失败则重新打开
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// BreakerConfig 描述熔断条件
+type BreakerConfig struct {
+	// Window 统计失败率的最近调用次数，小于1时按20处理
+	Window int
+	// MinCalls 窗口内至少有这么多次调用才计算失败率，小于1时按 Window 处理
+	MinCalls int
+	// FailureRate 失败率达到该值（0到1之间）时打开熔断器，不大于0时按0.5处理
+	FailureRate float64
+	// Cooldown 打开后等待多久进入半开状态，不大于0时按1秒处理
+	Cooldown time.Duration
+	// Clock 时间来源，nil表示使用系统时间
+	Clock ratelimit.Clock
+}
+
+// CircuitBreaker 根据最近调用的失败率决定是否绕过一个阶段，可以被多个goroutine共享
+type CircuitBreaker struct {
+	cfg BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	outcomes []bool // 环形缓冲区，true表示失败
+	next     int
+	count    int
+	failures int
+	openedAt time.Time
+	probing  bool // 半开状态下试探调用是否已经放出
+}
+
+// NewCircuitBreaker 创建一个处于关闭状态的熔断器
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Window < 1 {
+		cfg.Window = 20
+	}
+	if cfg.MinCalls < 1 || cfg.MinCalls > cfg.Window {
+		cfg.MinCalls = cfg.Window
+	}
+	if cfg.FailureRate <= 0 {
+		cfg.FailureRate = 0.5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = time.Second
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = ratelimit.RealClock{}
+	}
+	return &CircuitBreaker{cfg: cfg, outcomes: make([]bool, cfg.Window)}
+}
+
+// State 返回当前状态，冷却时间已过的打开状态报告为半开
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	return b.state
+}
+
+// advance 冷却时间结束后从打开转为半开，调用方必须持有mu
+func (b *CircuitBreaker) advance() {
+	if b.state == BreakerOpen && b.cfg.Clock.Now().Sub(b.openedAt) >= b.cfg.Cooldown {
+		b.state = BreakerHalfOpen
+		b.probing = false
+	}
+}
+
+// Allow 判断这次调用是否可以执行；返回true时调用方必须随后调用 Record
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.advance()
+	switch b.state {
+	case BreakerClosed:
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Record 记录一次被放行调用的结果
+func (b *CircuitBreaker) Record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if err != nil {
+			b.open()
+		} else {
+			b.reset()
+		}
+	case BreakerClosed:
+		b.push(err != nil)
+		if b.count >= b.cfg.MinCalls && float64(b.failures)/float64(b.count) >= b.cfg.FailureRate {
+			b.open()
+		}
+	}
+	// 打开状态下的结果来自打开之前放出的调用，不再统计
+}
+
+// push 把一次结果写入滑动窗口
+func (b *CircuitBreaker) push(failed bool) {
+	if b.count == len(b.outcomes) {
+		if b.outcomes[b.next] {
+			b.failures--
+		}
+	} else {
+		b.count++
+	}
+	b.outcomes[b.next] = failed
+	if failed {
+		b.failures++
+	}
+	b.next = (b.next + 1) % len(b.outcomes)
+}
+
+func (b *CircuitBreaker) open() {
+	b.state = BreakerOpen
+	b.openedAt = b.cfg.Clock.Now()
+	b.probing = false
+}
+
+// reset 关闭熔断器并清空窗口，之前的失败不再影响新的统计
+func (b *CircuitBreaker) reset() {
+	b.state = BreakerClosed
+	b.probing = false
+	b.next, b.count, b.failures = 0, 0, 0
+	for i := range b.outcomes {
+		b.outcomes[i] = false
+	}
+}
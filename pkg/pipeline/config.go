@@ -0,0 +1,282 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// Config 描述一条Pipeline，可以从JSON或YAML文件加载
+//
+//	name: 示例
+//	stages:
+//	  - stage: 加倍
+//	  - stage: 平方
+//	    parallelism: 4
+//	    buffer: 10
+//	  - stage: 加
+//	    params: {n: 5}
+//	    rate: 100  # 每秒最多处理100个
+//	    burst: 10
+type Config struct {
+	Name   string        `json:"name" yaml:"name"`
+	Stages []StageConfig `json:"stages" yaml:"stages"`
+}
+
+// StageConfig 描述Pipeline中的一个阶段
+type StageConfig struct {
+	Stage       string `json:"stage" yaml:"stage"`             // 登记表中的阶段名
+	Parallelism int    `json:"parallelism" yaml:"parallelism"` // 并行goroutine数，0表示1，并行时输出仍保持输入顺序
+	Buffer      int    `json:"buffer" yaml:"buffer"`           // 输出Channel缓冲区大小
+	Params      Params `json:"params" yaml:"params"`           // 传给阶段工厂的参数
+
+	// Rate 大于0时用令牌桶限制该阶段每秒处理的元素数，所有并行goroutine共享
+	Rate  float64 `json:"rate" yaml:"rate"`
+	Burst int     `json:"burst" yaml:"burst"` // 令牌桶容量，0表示1
+}
+
+// LoadConfig 从文件加载配置，.yaml/.yml 按YAML解析，其他按JSON解析
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ParseConfig(data, "yaml")
+	default:
+		return ParseConfig(data, "json")
+	}
+}
+
+// ParseConfig 解析配置内容，format 为 "json" 或 "yaml"
+func ParseConfig(data []byte, format string) (*Config, error) {
+	var cfg Config
+	var err error
+
+	switch format {
+	case "json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	case "yaml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	default:
+		return nil, fmt.Errorf("不支持的配置格式: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析%s配置失败: %w", format, err)
+	}
+	return &cfg, nil
+}
+
+// Validate 检查配置是否能用登记表构建，返回所有问题的合并错误
+func Validate[T any](c *Config, reg *Registry[T]) error {
+	_, err := compile(c, reg)
+	return err
+}
+
+// compiledStage 是已经根据参数创建好的阶段
+type compiledStage[T any] struct {
+	name        string
+	fn          StageFunc[T]
+	parallelism int
+	buffer      int
+	limiter     Waiter // 为nil时不限流
+}
+
+// compile 检查配置并创建所有阶段
+func compile[T any](c *Config, reg *Registry[T]) ([]compiledStage[T], error) {
+	var errs []error
+	if len(c.Stages) == 0 {
+		errs = append(errs, errors.New("至少需要一个阶段"))
+	}
+
+	stages := make([]compiledStage[T], 0, len(c.Stages))
+	for i, sc := range c.Stages {
+		where := fmt.Sprintf("第%d个阶段(%s)", i+1, sc.Stage)
+
+		factory, ok := reg.Lookup(sc.Stage)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: 未登记的阶段, 可用阶段: %s",
+				where, strings.Join(reg.Names(), ", ")))
+			continue
+		}
+		if sc.Parallelism < 0 {
+			errs = append(errs, fmt.Errorf("%s: parallelism 不能为负数", where))
+		}
+		if sc.Buffer < 0 {
+			errs = append(errs, fmt.Errorf("%s: buffer 不能为负数", where))
+		}
+		if sc.Rate < 0 || sc.Burst < 0 {
+			errs = append(errs, fmt.Errorf("%s: rate 和 burst 不能为负数", where))
+		}
+
+		fn, err := factory(sc.Params)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			continue
+		}
+
+		parallelism := sc.Parallelism
+		if parallelism == 0 {
+			parallelism = 1
+		}
+		stage := compiledStage[T]{
+			name:        sc.Stage,
+			fn:          fn,
+			parallelism: parallelism,
+			buffer:      sc.Buffer,
+		}
+		if sc.Rate > 0 {
+			stage.limiter = ratelimit.NewTokenBucket(sc.Rate, sc.Burst, nil)
+		}
+		stages = append(stages, stage)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
+// Configured 是根据配置构建好的Pipeline
+type Configured[T any] struct {
+	Name    string
+	stages  []compiledStage[T]
+	monitor *Monitor
+}
+
+// Build 根据配置和登记表构建Pipeline，配置有误时返回错误
+func Build[T any](c *Config, reg *Registry[T]) (*Configured[T], error) {
+	stages, err := compile(c, reg)
+	if err != nil {
+		return nil, err
+	}
+	return &Configured[T]{Name: c.Name, stages: stages}, nil
+}
+
+// WithMonitor 让之后的 Run 把各阶段的指标记录到m
+// 同名阶段可能出现多次，所以监控中的阶段名带有序号，例如 "2.加"
+func (p *Configured[T]) WithMonitor(m *Monitor) *Configured[T] {
+	p.monitor = m
+	return p
+}
+
+// Run 把in依次接入各个阶段，返回最后一个阶段的 Result 流
+func (p *Configured[T]) Run(ctx context.Context, in <-chan T) <-chan Result[T] {
+	cur := Lift(ctx, in)
+	for i, s := range p.stages {
+		var sm *StageMonitor
+		if p.monitor != nil {
+			sm = p.monitor.Stage(fmt.Sprintf("%d.%s", i+1, s.name))
+		}
+		cur = runStage(ctx, s, sm, cur)
+	}
+	return cur
+}
+
+// runStage 用 parallelism 个goroutine运行一个阶段，输出顺序与输入相同
+// sm 为nil时不记录指标
+func runStage[T any](ctx context.Context, s compiledStage[T], sm *StageMonitor, in <-chan Result[T]) <-chan Result[T] {
+	out := make(chan Result[T], s.buffer)
+	watchOutput(sm, out)
+
+	fn := s.fn
+	if s.limiter != nil {
+		fn = Limited(ctx, s.limiter, fn)
+	}
+	apply := func(r Result[T]) Result[T] {
+		if r.Err != nil {
+			return r
+		}
+		start := time.Now()
+		v, err := fn(r.Value)
+		sm.observe(start, err)
+		if err != nil {
+			return Fail[T](&StageError{Stage: s.name, Input: r.Value, Err: err})
+		}
+		return Ok(v)
+	}
+
+	if s.parallelism == 1 {
+		go func() {
+			defer close(out)
+			sm.workerStarted()
+			defer sm.workerStopped()
+			for {
+				r, ok := monitoredRecv(ctx, sm, in)
+				if !ok || !monitoredSend(ctx, sm, out, apply(r)) {
+					return
+				}
+			}
+		}()
+		return out
+	}
+
+	// 并行时每个元素带一个结果槽，槽按输入顺序排队，输出时依次等待；
+	// 队列容量为parallelism，前面的元素没处理完时最多再领取这么多个
+	type job struct {
+		r    Result[T]
+		done chan Result[T]
+	}
+	jobs := make(chan job)
+	order := make(chan chan Result[T], s.parallelism)
+
+	go func() {
+		defer close(jobs)
+		defer close(order)
+		for {
+			r, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			done := make(chan Result[T], 1)
+			if !send(ctx, order, done) || !send(ctx, jobs, job{r: r, done: done}) {
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < s.parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sm.workerStarted()
+			defer sm.workerStopped()
+			for j := range jobs {
+				j.done <- apply(j.r) // 容量为1，不会阻塞
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			wg.Wait()
+			close(out)
+		}()
+		for done := range order {
+			r, ok := recv(ctx, done)
+			if !ok || !monitoredSend(ctx, sm, out, r) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
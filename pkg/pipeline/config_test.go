@@ -0,0 +1,208 @@
+package pipeline
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runConfigured 用配置运行 [from, to] 的整数并返回排序后的结果
+func runConfigured(t *testing.T, cfg *Config, from, to int) ([]int, ErrorReport) {
+	t.Helper()
+
+	p, err := Build(cfg, IntRegistry())
+	if err != nil {
+		t.Fatalf("Build 返回错误: %v", err)
+	}
+
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+	got := Collect(ctx, Handle(ctx, policy, p.Run(ctx, Generate(ctx, from, to))))
+	sort.Ints(got)
+	return got, policy.Report()
+}
+
+// TestLoadYAMLAndJSON 测试YAML和JSON描述的Pipeline得到相同的结果
+func TestLoadYAMLAndJSON(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlPath := filepath.Join(dir, "p.yaml")
+	os.WriteFile(yamlPath, []byte(`
+name: 动态Pipeline
+stages:
+  - stage: 加倍
+  - stage: 平方
+    parallelism: 3
+    buffer: 5
+  - stage: 加
+    params:
+      n: 5
+`), 0o644)
+
+	jsonPath := filepath.Join(dir, "p.json")
+	os.WriteFile(jsonPath, []byte(`{
+  "name": "动态Pipeline",
+  "stages": [
+    {"stage": "加倍"},
+    {"stage": "平方", "parallelism": 3, "buffer": 5},
+    {"stage": "加", "params": {"n": 5}}
+  ]
+}`), 0o644)
+
+	// dynamicPipeline 的结果：加倍 → 平方 → 加5
+	want := []int{9, 21, 41, 69, 105}
+
+	for _, path := range []string{yamlPath, jsonPath} {
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatalf("LoadConfig(%s) 返回错误: %v", filepath.Base(path), err)
+		}
+		if cfg.Stages[1].Parallelism != 3 || cfg.Stages[1].Buffer != 5 {
+			t.Errorf("%s: 第2个阶段配置 %+v", filepath.Base(path), cfg.Stages[1])
+		}
+
+		got, report := runConfigured(t, cfg, 1, 5)
+		if len(got) != len(want) {
+			t.Fatalf("%s: 结果 %v, 期望 %v", filepath.Base(path), got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: 结果 %v, 期望 %v", filepath.Base(path), got, want)
+			}
+		}
+		if report.Failed() != 0 {
+			t.Errorf("%s: 意外错误 %v", filepath.Base(path), report.Err())
+		}
+	}
+}
+
+// TestValidateReportsAllProblems 测试校验一次报告所有问题
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := &Config{Stages: []StageConfig{
+		{Stage: "不存在"},
+		{Stage: "平方", Parallelism: -1},
+		{Stage: "除", Params: Params{"n": 0}},
+		{Stage: "加", Params: Params{"n": "五"}},
+	}}
+
+	err := Validate(cfg, IntRegistry())
+	if err == nil {
+		t.Fatal("期望校验失败")
+	}
+	for _, want := range []string{"第1个阶段(不存在)", "第2个阶段(平方)", "第3个阶段(除)", "第4个阶段(加)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误信息缺少 %q:\n%v", want, err)
+		}
+	}
+
+	if err := Validate(&Config{}, IntRegistry()); err == nil {
+		t.Error("没有阶段的配置应该校验失败")
+	}
+}
+
+// TestParseConfigRejectsUnknownFields 测试拼错的字段会被发现
+func TestParseConfigRejectsUnknownFields(t *testing.T) {
+	if _, err := ParseConfig([]byte(`{"stages":[{"stgae":"加倍"}]}`), "json"); err == nil {
+		t.Error("JSON中的未知字段应该报错")
+	}
+	if _, err := ParseConfig([]byte("stages:\n  - stage: 加倍\n    paralel: 2\n"), "yaml"); err == nil {
+		t.Error("YAML中的未知字段应该报错")
+	}
+	if _, err := ParseConfig([]byte(`{}`), "toml"); err == nil {
+		t.Error("不支持的格式应该报错")
+	}
+}
+
+// TestRegistry 测试登记和查找
+func TestRegistry(t *testing.T) {
+	reg := NewRegistry[string]()
+	if err := reg.RegisterFunc("大写", strings.ToUpper); err != nil {
+		t.Fatal(err)
+	}
+	if err := reg.RegisterFunc("大写", strings.ToUpper); err == nil {
+		t.Error("重复登记应该返回错误")
+	}
+
+	f, ok := reg.Lookup("大写")
+	if !ok {
+		t.Fatal("找不到已登记的阶段")
+	}
+	fn, _ := f(nil)
+	if v, _ := fn("go"); v != "GO" {
+		t.Errorf("大写(go) = %q", v)
+	}
+	if names := reg.Names(); len(names) != 1 || names[0] != "大写" {
+		t.Errorf("Names() = %v", names)
+	}
+}
+
+// TestConfiguredStageError 测试阶段错误带有阶段名
+func TestConfiguredStageError(t *testing.T) {
+	reg := IntRegistry()
+	reg.Register("拒绝负数", func(Params) (StageFunc[int], error) {
+		return func(x int) (int, error) {
+			if x < 0 {
+				return 0, os.ErrInvalid
+			}
+			return x, nil
+		}, nil
+	})
+
+	cfg := &Config{Stages: []StageConfig{{Stage: "加", Params: Params{"n": -3}}, {Stage: "拒绝负数"}}}
+	p, err := Build(cfg, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+	got := Collect(ctx, Handle(ctx, policy, p.Run(ctx, Generate(ctx, 1, 5))))
+
+	if len(got) != 3 {
+		t.Errorf("结果 %v, 期望3个非负数", got)
+	}
+	if by := policy.Report().ByStage(); by["拒绝负数"] != 2 {
+		t.Errorf("按阶段统计 %v", by)
+	}
+}
+
+// TestConfiguredParallelOrder 测试并行阶段的输出保持输入顺序，取消后goroutine全部退出
+func TestConfiguredParallelOrder(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	reg := IntRegistry()
+	reg.RegisterFunc("抖动", func(x int) int {
+		time.Sleep(time.Duration(x%4) * time.Millisecond) // 后到的元素可能先处理完
+		return x
+	})
+	cfg := &Config{Stages: []StageConfig{{Stage: "抖动", Parallelism: 4}, {Stage: "加倍", Parallelism: 3}}}
+	p, err := Build(cfg, reg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	var got []int
+	for r := range p.Run(ctx, Generate(ctx, 1, 200)) {
+		got = append(got, r.Value)
+	}
+	if len(got) != 200 {
+		t.Fatalf("收到 %d 个结果, 期望200", len(got))
+	}
+	for i, v := range got {
+		if v != (i+1)*2 {
+			t.Fatalf("第%d个结果是 %d, 期望 %d: 输出顺序被打乱", i, v, (i+1)*2)
+		}
+	}
+
+	cctx, cancel := context.WithCancel(ctx)
+	out := p.Run(cctx, Generate(cctx, 1, 1000))
+	<-out
+	cancel()
+	checkGoroutineLeak(t, baseline)
+}
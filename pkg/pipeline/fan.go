@@ -0,0 +1,285 @@
+package pipeline
+
+import (
+	"container/heap"
+	"context"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Strategy 决定 FanOut 把每个元素发给哪些输出
+//
+// 一个Strategy值可能带有状态（例如轮询的位置），只应交给一个 FanOut 使用。
+type Strategy[T any] interface {
+	// Route 返回v的目标输出下标，loads[i]是第i个输出缓冲区中还没被取走的元素数
+	// broadcast为true时v会发送给targets中的每一个输出；
+	// 否则发送给targets中第一个能立即接收的输出，都不能立即接收时发给最先就绪的那个
+	Route(v T, loads []int) (targets []int, broadcast bool)
+}
+
+// roundRobin 依次轮流选择输出，与练习4的fanOut相同
+type roundRobin[T any] struct {
+	next   int
+	target [1]int
+}
+
+// RoundRobin 依次轮流把元素发给每个输出，某个消费者慢时会阻塞整个分发
+func RoundRobin[T any]() Strategy[T] {
+	return &roundRobin[T]{}
+}
+
+func (s *roundRobin[T]) Route(_ T, loads []int) ([]int, bool) {
+	s.target[0] = s.next % len(loads)
+	s.next = s.target[0] + 1
+	return s.target[:], false
+}
+
+// leastLoaded 优先选择空闲的输出
+type leastLoaded[T any] struct {
+	order []int
+}
+
+// LeastLoaded 优先发给正在等待的消费者，其次是缓冲区中积压最少的输出
+// 慢消费者只会少分到元素，不会拖住其他消费者
+func LeastLoaded[T any]() Strategy[T] {
+	return &leastLoaded[T]{}
+}
+
+func (s *leastLoaded[T]) Route(_ T, loads []int) ([]int, bool) {
+	if len(s.order) != len(loads) {
+		s.order = make([]int, len(loads))
+	}
+	for i := range s.order {
+		s.order[i] = i
+	}
+	sort.SliceStable(s.order, func(a, b int) bool { return loads[s.order[a]] < loads[s.order[b]] })
+	return s.order, false
+}
+
+// hashByKey 同一个key总是发给同一个输出
+type hashByKey[T any] struct {
+	key    func(T) string
+	target [1]int
+}
+
+// HashBy 按key的哈希值选择输出，同一个key的元素总是由同一个消费者按顺序处理，
+// 适合按用户、按会话聚合这类需要粘性路由的场景
+func HashBy[T any](key func(T) string) Strategy[T] {
+	return &hashByKey[T]{key: key}
+}
+
+func (s *hashByKey[T]) Route(v T, loads []int) ([]int, bool) {
+	h := fnv.New32a()
+	h.Write([]byte(s.key(v)))
+	s.target[0] = int(h.Sum32() % uint32(len(loads)))
+	return s.target[:], false
+}
+
+// broadcast 把每个元素发给所有输出
+type broadcast[T any] struct {
+	all []int
+}
+
+// Broadcast 把每个元素发给所有输出，最慢的消费者决定整体速度
+// 元素是指针或切片时，所有消费者共享同一份数据，不应修改它
+func Broadcast[T any]() Strategy[T] {
+	return &broadcast[T]{}
+}
+
+func (s *broadcast[T]) Route(_ T, loads []int) ([]int, bool) {
+	if len(s.all) != len(loads) {
+		s.all = make([]int, len(loads))
+		for i := range s.all {
+			s.all[i] = i
+		}
+	}
+	return s.all, true
+}
+
+// FanOut 按strategy把in中的元素分发到n个输出，每个输出的缓冲区大小为buffer
+// in关闭或ctx取消后所有输出都会被关闭
+func FanOut[T any](ctx context.Context, in <-chan T, n, buffer int, strategy Strategy[T]) []<-chan T {
+	if n < 1 {
+		n = 1
+	}
+	outs := make([]chan T, n)
+	result := make([]<-chan T, n)
+	for i := range outs {
+		outs[i] = make(chan T, buffer)
+		result[i] = outs[i]
+	}
+
+	go func() {
+		defer func() {
+			for _, out := range outs {
+				close(out)
+			}
+		}()
+
+		loads := make([]int, n)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+
+			for i, out := range outs {
+				loads[i] = len(out)
+			}
+			targets, all := strategy.Route(v, loads)
+			if len(targets) == 0 {
+				continue
+			}
+
+			if all {
+				for _, i := range targets {
+					if !send(ctx, outs[i], v) {
+						return
+					}
+				}
+				continue
+			}
+			if !sendFirstReady(ctx, outs, targets, v) {
+				return
+			}
+		}
+	}()
+
+	return result
+}
+
+// sendFirstReady 发给targets中第一个能立即接收的输出，都不能时等待最先就绪的那个
+func sendFirstReady[T any](ctx context.Context, outs []chan T, targets []int, v T) bool {
+	if len(targets) == 1 {
+		return send(ctx, outs[targets[0]], v)
+	}
+
+	for _, i := range targets {
+		select {
+		case outs[i] <- v:
+			return true
+		default:
+		}
+	}
+
+	// 候选输出的数量在运行时才知道，只能用反射构造select
+	cases := make([]reflect.SelectCase, 0, len(targets)+1)
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	value := reflect.ValueOf(&v).Elem()
+	for _, i := range targets {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(outs[i]), Send: value})
+	}
+	chosen, _, _ := reflect.Select(cases)
+	return chosen != 0
+}
+
+// Merge 把多个输入合并为一个输出，是练习3中fanIn的可取消版本
+// 输出顺序不确定；所有输入关闭或ctx取消后输出被关闭
+func Merge[T any](ctx context.Context, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+
+	for _, input := range inputs {
+		wg.Add(1)
+		go func(ch <-chan T) {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, ch)
+				if !ok {
+					return
+				}
+				if !send(ctx, out, v) {
+					return
+				}
+			}
+		}(input)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// MergeSorted 合并多个已经按less排好序的输入，输出整体有序
+// 每次需要等所有未关闭的输入都给出下一个元素才能决定输出哪个，
+// 所以最慢的输入决定整体速度；相等的元素按输入的先后顺序输出
+func MergeSorted[T any](ctx context.Context, less func(a, b T) bool, inputs ...<-chan T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+
+		h := &mergeHeap[T]{less: less}
+		for i, input := range inputs {
+			v, ok := recv(ctx, input)
+			if ok {
+				h.items = append(h.items, mergeItem[T]{value: v, source: i})
+			} else if ctx.Err() != nil {
+				return
+			}
+		}
+		heap.Init(h)
+
+		for h.Len() > 0 {
+			top := h.items[0]
+			if !send(ctx, out, top.value) {
+				return
+			}
+
+			// 从刚输出的那个输入补充下一个元素
+			v, ok := recv(ctx, inputs[top.source])
+			if ctx.Err() != nil {
+				return
+			}
+			if ok {
+				h.items[0].value = v
+				heap.Fix(h, 0)
+			} else {
+				heap.Pop(h)
+			}
+		}
+	}()
+
+	return out
+}
+
+// mergeItem 是某个输入当前的队首元素
+type mergeItem[T any] struct {
+	value  T
+	source int
+}
+
+// mergeHeap 按值排序，值相等时按输入下标排序
+type mergeHeap[T any] struct {
+	items []mergeItem[T]
+	less  func(a, b T) bool
+}
+
+func (h *mergeHeap[T]) Len() int { return len(h.items) }
+
+func (h *mergeHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.less(a.value, b.value) {
+		return true
+	}
+	if h.less(b.value, a.value) {
+		return false
+	}
+	return a.source < b.source
+}
+
+func (h *mergeHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *mergeHeap[T]) Push(x any) { h.items = append(h.items, x.(mergeItem[T])) }
+
+func (h *mergeHeap[T]) Pop() any {
+	old := h.items
+	item := old[len(old)-1]
+	h.items = old[:len(old)-1]
+	return item
+}
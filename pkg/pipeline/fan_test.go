@@ -0,0 +1,171 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectAll 并发读取所有输出，返回每个输出收到的元素
+func collectAll[T any](ctx context.Context, outs []<-chan T) [][]T {
+	got := make([][]T, len(outs))
+	var wg sync.WaitGroup
+	for i, out := range outs {
+		wg.Add(1)
+		go func(i int, out <-chan T) {
+			defer wg.Done()
+			got[i] = Collect(ctx, out)
+		}(i, out)
+	}
+	wg.Wait()
+	return got
+}
+
+// TestFanOutRoundRobin 测试轮询分发与练习4的fanOut结果一致
+func TestFanOutRoundRobin(t *testing.T) {
+	ctx := context.Background()
+	got := collectAll(ctx, FanOut(ctx, Generate(ctx, 1, 9), 3, 10, RoundRobin[int]()))
+
+	want := [][]int{{1, 4, 7}, {2, 5, 8}, {3, 6, 9}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("分发结果 %v, 期望 %v", got, want)
+	}
+}
+
+// TestFanOutLeastLoaded 测试慢消费者不会拖住快消费者
+func TestFanOutLeastLoaded(t *testing.T) {
+	const n = 100
+	ctx := context.Background()
+	outs := FanOut(ctx, Generate(ctx, 1, n), 2, 0, LeastLoaded[int]())
+
+	var slow, fast int
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range outs[0] {
+			slow++
+			time.Sleep(5 * time.Millisecond)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range outs[1] {
+			fast++
+		}
+	}()
+
+	start := time.Now()
+	wg.Wait()
+
+	if slow+fast != n {
+		t.Fatalf("共收到 %d 个元素, 期望 %d", slow+fast, n)
+	}
+	if slow > 10 {
+		t.Errorf("慢消费者分到 %d 个元素, 应当远少于快消费者 (%d)", slow, fast)
+	}
+	// 轮询需要 50*5ms；最少负载只受慢消费者手上那几个元素限制
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("分发耗时 %v, 慢消费者拖住了整体", elapsed)
+	}
+}
+
+// TestFanOutHashBy 测试同一个key的元素总是发给同一个输出且保持顺序
+func TestFanOutHashBy(t *testing.T) {
+	type event struct {
+		user string
+		seq  int
+	}
+	var events []event
+	for i := 0; i < 60; i++ {
+		events = append(events, event{user: "user" + strconv.Itoa(i%6), seq: i})
+	}
+
+	ctx := context.Background()
+	outs := FanOut(ctx, FromSlice(ctx, events), 3, 0, HashBy(func(e event) string { return e.user }))
+	got := collectAll(ctx, outs)
+
+	owner := make(map[string]int)
+	total := 0
+	for i, evs := range got {
+		last := make(map[string]int)
+		for _, e := range evs {
+			if o, ok := owner[e.user]; ok && o != i {
+				t.Errorf("%s 同时出现在输出 %d 和 %d", e.user, o, i)
+			}
+			owner[e.user] = i
+			if e.seq < last[e.user] {
+				t.Errorf("%s 的事件乱序: %d 在 %d 之后", e.user, e.seq, last[e.user])
+			}
+			last[e.user] = e.seq
+			total++
+		}
+	}
+	if total != len(events) {
+		t.Errorf("共收到 %d 个事件, 期望 %d", total, len(events))
+	}
+}
+
+// TestFanOutBroadcast 测试每个输出都收到全部元素
+func TestFanOutBroadcast(t *testing.T) {
+	ctx := context.Background()
+	got := collectAll(ctx, FanOut(ctx, Generate(ctx, 1, 5), 3, 0, Broadcast[int]()))
+
+	want := []int{1, 2, 3, 4, 5}
+	for i, g := range got {
+		if !reflect.DeepEqual(g, want) {
+			t.Errorf("输出 %d 收到 %v, 期望 %v", i, g, want)
+		}
+	}
+}
+
+// TestMerge 测试合并所有输入
+func TestMerge(t *testing.T) {
+	ctx := context.Background()
+	got := Collect(ctx, Merge(ctx, Generate(ctx, 1, 3), Generate(ctx, 4, 6), Generate(ctx, 7, 9)))
+	sort.Ints(got)
+
+	if !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("合并结果 %v", got)
+	}
+}
+
+// TestMergeSorted 测试有序合并，相等的元素按输入顺序输出
+func TestMergeSorted(t *testing.T) {
+	type item struct {
+		key int
+		src string
+	}
+	ctx := context.Background()
+	a := FromSlice(ctx, []item{{1, "a"}, {4, "a"}, {7, "a"}})
+	b := FromSlice(ctx, []item{{2, "b"}, {4, "b"}, {8, "b"}, {9, "b"}})
+	c := FromSlice(ctx, []item{})
+	d := FromSlice(ctx, []item{{0, "d"}, {4, "d"}})
+
+	got := Collect(ctx, MergeSorted(ctx, func(x, y item) bool { return x.key < y.key }, a, b, c, d))
+	want := []item{{0, "d"}, {1, "a"}, {2, "b"}, {4, "a"}, {4, "b"}, {4, "d"}, {7, "a"}, {8, "b"}, {9, "b"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("合并结果 %v\n期望 %v", got, want)
+	}
+}
+
+// TestFanCancel 测试取消后扇出扇入的goroutine全部退出
+func TestFanCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 输出没有人读取，所有goroutine都阻塞在发送上，只能靠取消退出
+	src := Generate(ctx, 0, int(^uint(0)>>1))
+	outs := FanOut(ctx, src, 3, 1, Broadcast[int]())
+	Merge(ctx, outs[0], outs[1])
+	MergeSorted(ctx, func(a, b int) bool { return a < b }, outs[2], Generate(ctx, 0, 10))
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	checkGoroutineLeak(t, baseline)
+}
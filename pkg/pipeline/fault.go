@@ -0,0 +1,160 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/internal/retry"
+)
+
+// RetryPolicy 描述阶段处理失败后的重试方式，零值表示不重试
+// 与 workerpool.RetryPolicy 是同一个类型，字段含义见 retry.Policy；
+// Retryable 判断为不值得重试的错误直接交给备用处理
+type RetryPolicy = retry.Policy
+
+// Attempt 是一次处理尝试的记录
+type Attempt struct {
+	N        int  // 第几次尝试，主处理和备用处理分别从1开始计数
+	Fallback bool // 是否由备用处理函数执行
+	Err      error
+	At       time.Time
+}
+
+func (a Attempt) String() string {
+	who := "主处理"
+	if a.Fallback {
+		who = "备用处理"
+	}
+	return fmt.Sprintf("%s第%d次: %v", who, a.N, a.Err)
+}
+
+// FailureHistory 是一个元素所有失败尝试的记录，最终失败时作为 StageError.Err
+type FailureHistory struct {
+	Attempts []Attempt
+}
+
+func (h *FailureHistory) Error() string {
+	parts := make([]string, len(h.Attempts))
+	for i, a := range h.Attempts {
+		parts[i] = a.String()
+	}
+	return fmt.Sprintf("%d次尝试均失败 [%s]", len(h.Attempts), strings.Join(parts, "; "))
+}
+
+// Unwrap 让 errors.Is / errors.As 可以匹配任意一次尝试的错误
+func (h *FailureHistory) Unwrap() []error {
+	errs := make([]error, len(h.Attempts))
+	for i, a := range h.Attempts {
+		errs[i] = a.Err
+	}
+	return errs
+}
+
+// History 从错误链中取出失败历史，不是容错阶段产生的错误时返回nil
+func History(err error) []Attempt {
+	var h *FailureHistory
+	if errors.As(err, &h) {
+		return h.Attempts
+	}
+	return nil
+}
+
+// FaultTolerance 配置 Resilient 阶段
+//
+// 一个元素的处理顺序：
+//  1. 熔断器关闭时调用 Process，失败后按 Retry 退避重试
+//  2. 重试用完或熔断器打开时，调用一次 Fallback（如果有）
+//  3. 仍然失败的元素以 *StageError 的形式进入 Result 流，其中 Err 是 *FailureHistory；
+//     交给 DeadLetter 模式的 Handle 即可把它们送到死信Channel
+//
+// 熔断器打开时 Process 被整个绕过；输入输出类型相同时，
+// 把 Fallback 设为原样返回的函数即可让元素直接跳过这个阶段。
+type FaultTolerance[T, U any] struct {
+	Process  func(T) (U, error)
+	Retry    RetryPolicy
+	Fallback func(T) (U, error)
+	Breaker  *CircuitBreaker
+}
+
+// Resilient 按 FaultTolerance 的配置处理 Result 流中成功的元素，上游的错误原样向下传递
+func Resilient[T, U any](ctx context.Context, stage string, in <-chan Result[T], ft FaultTolerance[T, U]) <-chan Result[U] {
+	out := make(chan Result[U])
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			r, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+
+			var next Result[U]
+			if r.Err != nil {
+				next.Err = r.Err
+			} else {
+				start := sm.now()
+				v, history := ft.run(ctx, r.Value)
+				if history != nil {
+					next.Err = &StageError{Stage: stage, Input: r.Value, Err: history}
+				} else {
+					next.Value = v
+				}
+				sm.observe(start, next.Err)
+			}
+
+			if !monitoredSend(ctx, sm, out, next) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// run 处理一个元素，成功时返回的历史为nil
+func (ft FaultTolerance[T, U]) run(ctx context.Context, v T) (U, *FailureHistory) {
+	history := &FailureHistory{}
+
+	maxAttempts := ft.Retry.Attempts()
+	for n := 1; n <= maxAttempts; n++ {
+		if ft.Breaker != nil && !ft.Breaker.Allow() {
+			history.Attempts = append(history.Attempts, Attempt{N: n, Err: ErrCircuitOpen, At: time.Now()})
+			break
+		}
+
+		u, err := ft.Process(v)
+		if ft.Breaker != nil {
+			ft.Breaker.Record(err)
+		}
+		if err == nil {
+			return u, nil
+		}
+		history.Attempts = append(history.Attempts, Attempt{N: n, Err: err, At: time.Now()})
+
+		if n == maxAttempts || !ft.Retry.ShouldRetry(err) {
+			break
+		}
+		if !retry.Sleep(ctx, ft.Retry.Backoff(n)) {
+			var zero U
+			return zero, history
+		}
+	}
+
+	if ft.Fallback != nil && ctx.Err() == nil {
+		u, err := ft.Fallback(v)
+		if err == nil {
+			return u, nil
+		}
+		history.Attempts = append(history.Attempts, Attempt{N: 1, Fallback: true, Err: err, At: time.Now()})
+	}
+
+	var zero U
+	return zero, history
+}
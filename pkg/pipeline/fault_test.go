@@ -0,0 +1,262 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+var errFlaky = errors.New("暂时失败")
+
+// flaky 返回一个处理函数，每个输入的前failures次调用失败
+func flaky(failures int) func(int) (int, error) {
+	var mu sync.Mutex
+	calls := make(map[int]int)
+	return func(x int) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[x]++
+		if calls[x] <= failures {
+			return 0, errFlaky
+		}
+		return x * 10, nil
+	}
+}
+
+// TestResilientRetry 测试失败的元素经过重试后成功
+func TestResilientRetry(t *testing.T) {
+	ctx := context.Background()
+	out := Resilient(ctx, "乘10", Lift(ctx, Generate(ctx, 1, 5)), FaultTolerance[int, int]{
+		Process: flaky(2),
+		Retry:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+	})
+
+	for r := range out {
+		if r.Err != nil {
+			t.Errorf("重试3次应当成功, 实际错误: %v", r.Err)
+		}
+	}
+}
+
+// TestResilientFallback 测试重试用完后交给备用处理函数
+func TestResilientFallback(t *testing.T) {
+	ctx := context.Background()
+	var primaryCalls int
+	out := Resilient(ctx, "乘10", Lift(ctx, Generate(ctx, 1, 3)), FaultTolerance[int, int]{
+		Process: func(x int) (int, error) {
+			primaryCalls++
+			return 0, errFlaky
+		},
+		Retry:    RetryPolicy{MaxAttempts: 2},
+		Fallback: func(x int) (int, error) { return -x, nil },
+	})
+
+	var got []int
+	for r := range out {
+		if r.Err != nil {
+			t.Fatalf("备用处理成功时不应有错误: %v", r.Err)
+		}
+		got = append(got, r.Value)
+	}
+	if len(got) != 3 || got[0] != -1 || got[2] != -3 {
+		t.Errorf("结果 %v, 期望 [-1 -2 -3]", got)
+	}
+	if primaryCalls != 6 {
+		t.Errorf("主处理应被调用6次, 实际 %d", primaryCalls)
+	}
+}
+
+// TestResilientNotRetryable 测试 Retryable 判断为不值得重试的错误不再重试
+func TestResilientNotRetryable(t *testing.T) {
+	ctx := context.Background()
+	errBadInput := errors.New("输入无效")
+	var calls int
+	out := Resilient(ctx, "校验", Lift(ctx, Generate(ctx, 1, 1)), FaultTolerance[int, int]{
+		Process: func(int) (int, error) {
+			calls++
+			return 0, errBadInput
+		},
+		Retry: RetryPolicy{
+			MaxAttempts: 5,
+			Retryable:   func(err error) bool { return !errors.Is(err, errBadInput) },
+		},
+	})
+
+	for r := range out {
+		if !errors.Is(r.Err, errBadInput) {
+			t.Errorf("期望 errBadInput, 实际 %v", r.Err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("不值得重试的错误应只处理1次, 实际 %d", calls)
+	}
+}
+
+// TestResilientDeadLetterHistory 测试最终失败的元素带着完整的失败历史进入死信Channel
+func TestResilientDeadLetterHistory(t *testing.T) {
+	errFallback := errors.New("备用也失败")
+	ctx, policy := NewErrorPolicy(context.Background(), DeadLetter)
+	defer policy.Stop()
+
+	in := Lift(ctx, FromSlice(ctx, []int{1, 2}))
+	stage := Resilient(ctx, "写入", in, FaultTolerance[int, int]{
+		Process: func(x int) (int, error) {
+			if x == 2 {
+				return 0, errFlaky
+			}
+			return x, nil
+		},
+		Retry:    RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+		Fallback: func(int) (int, error) { return 0, errFallback },
+	})
+	out := Handle(ctx, policy, stage)
+	policy.Close()
+
+	var dead []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range policy.DeadLetters() {
+			dead = append(dead, err)
+		}
+	}()
+
+	if got := Collect(ctx, out); len(got) != 1 || got[0] != 1 {
+		t.Errorf("结果 %v, 期望 [1]", got)
+	}
+	<-done
+
+	if len(dead) != 1 {
+		t.Fatalf("期望1个死信, 实际 %d", len(dead))
+	}
+	var se *StageError
+	if !errors.As(dead[0], &se) || se.Stage != "写入" || se.Input != 2 {
+		t.Fatalf("死信应为阶段 写入 处理 2 的 *StageError: %v", dead[0])
+	}
+
+	history := History(dead[0])
+	if len(history) != 4 {
+		t.Fatalf("期望3次主处理和1次备用处理, 实际 %v", history)
+	}
+	for i, a := range history[:3] {
+		if a.Fallback || a.N != i+1 || !errors.Is(a.Err, errFlaky) {
+			t.Errorf("第%d条记录错误: %v", i, a)
+		}
+	}
+	if !history[3].Fallback || !errors.Is(history[3].Err, errFallback) {
+		t.Errorf("最后一条应为备用处理的失败: %v", history[3])
+	}
+	if !errors.Is(dead[0], errFallback) || !errors.Is(dead[0], errFlaky) {
+		t.Error("errors.Is 应能匹配历史中的任意错误")
+	}
+}
+
+// TestCircuitBreakerStates 测试熔断器在关闭、打开、半开之间的转换
+func TestCircuitBreakerStates(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	b := NewCircuitBreaker(BreakerConfig{Window: 4, FailureRate: 0.5, Cooldown: time.Second, Clock: clock})
+
+	// 窗口未满时不计算失败率
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatal("关闭状态应放行")
+		}
+		b.Record(errFlaky)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("调用次数不足时不应打开, 实际 %v", b.State())
+	}
+	b.Allow()
+	b.Record(nil)
+	if b.State() != BreakerOpen {
+		t.Fatalf("失败率75%%应打开熔断器, 实际 %v", b.State())
+	}
+	if b.Allow() {
+		t.Error("打开状态不应放行")
+	}
+
+	clock.Advance(time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("冷却后应为半开, 实际 %v", b.State())
+	}
+	if !b.Allow() {
+		t.Fatal("半开状态应放行一次试探")
+	}
+	if b.Allow() {
+		t.Error("半开状态只放行一次试探")
+	}
+	b.Record(errFlaky)
+	if b.State() != BreakerOpen {
+		t.Fatalf("试探失败应重新打开, 实际 %v", b.State())
+	}
+
+	clock.Advance(time.Second)
+	b.Allow()
+	b.Record(nil)
+	if b.State() != BreakerClosed {
+		t.Fatalf("试探成功应关闭, 实际 %v", b.State())
+	}
+	// 关闭后窗口被清空，一次失败不会立即打开
+	b.Allow()
+	b.Record(errFlaky)
+	if b.State() != BreakerClosed {
+		t.Errorf("关闭后应重新统计, 实际 %v", b.State())
+	}
+}
+
+// TestResilientBreakerBypass 测试熔断器打开后主处理被绕过，元素直接交给备用处理
+func TestResilientBreakerBypass(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	breaker := NewCircuitBreaker(BreakerConfig{Window: 5, FailureRate: 0.6, Cooldown: time.Minute, Clock: clock})
+
+	ctx := context.Background()
+	var primaryCalls int
+	out := Resilient(ctx, "远程调用", Lift(ctx, Generate(ctx, 1, 20)), FaultTolerance[int, int]{
+		Process: func(x int) (int, error) {
+			primaryCalls++
+			return 0, errFlaky
+		},
+		Fallback: func(x int) (int, error) { return x, nil }, // 原样返回，相当于跳过这个阶段
+		Breaker:  breaker,
+	})
+
+	count := 0
+	for r := range out {
+		if r.Err != nil {
+			t.Errorf("有备用处理时不应有错误: %v", r.Err)
+		}
+		count++
+	}
+
+	if count != 20 {
+		t.Errorf("期望20个结果, 实际 %d", count)
+	}
+	if primaryCalls != 5 {
+		t.Errorf("窗口满5次失败后应熔断, 主处理被调用 %d 次", primaryCalls)
+	}
+	if breaker.State() != BreakerOpen {
+		t.Errorf("熔断器应保持打开, 实际 %v", breaker.State())
+	}
+}
+
+// TestResilientBreakerOpenNoFallback 测试没有备用处理时熔断的元素以 ErrCircuitOpen 失败
+func TestResilientBreakerOpenNoFallback(t *testing.T) {
+	breaker := NewCircuitBreaker(BreakerConfig{Window: 1, Cooldown: time.Minute})
+	breaker.Allow()
+	breaker.Record(errFlaky)
+
+	ctx := context.Background()
+	out := Resilient(ctx, "远程调用", Lift(ctx, Generate(ctx, 1, 1)), FaultTolerance[int, int]{
+		Process: func(x int) (int, error) { return x, nil },
+		Breaker: breaker,
+	})
+
+	r := <-out
+	if !errors.Is(r.Err, ErrCircuitOpen) {
+		t.Errorf("期望 ErrCircuitOpen, 实际 %v", r.Err)
+	}
+}
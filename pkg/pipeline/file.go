@@ -0,0 +1,258 @@
+package pipeline
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// MaxLineSize 是按行读取时单行的最大字节数，超过时该文件的读取以错误结束
+// 读取是流式的，内存占用只与单行长度和Channel缓冲区有关，与文件大小无关
+const MaxLineSize = 1 << 20
+
+// LineError 记录出错的文件和行号
+type LineError struct {
+	Path string
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ReadLines 逐行读取文件，行尾的换行符会被去掉
+// 打开或读取失败时发送一个错误并结束
+func ReadLines(ctx context.Context, path string) <-chan Result[string] {
+	out := make(chan Result[string])
+
+	go func() {
+		defer close(out)
+
+		f, err := os.Open(path)
+		if err != nil {
+			send(ctx, out, Fail[string](err))
+			return
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), MaxLineSize)
+		line := 0
+		for scanner.Scan() {
+			line++
+			if !send(ctx, out, Ok(scanner.Text())) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(ctx, out, Fail[string](&LineError{Path: path, Line: line + 1, Err: err}))
+		}
+	}()
+
+	return out
+}
+
+// ReadCSV 逐条读取CSV记录，第一条记录（通常是表头）也会原样输出
+// 格式错误的记录作为 *LineError 发送，读取继续；其他读取错误会结束读取
+func ReadCSV(ctx context.Context, path string) <-chan Result[[]string] {
+	out := make(chan Result[[]string])
+
+	go func() {
+		defer close(out)
+
+		f, err := os.Open(path)
+		if err != nil {
+			send(ctx, out, Fail[[]string](err))
+			return
+		}
+		defer f.Close()
+
+		r := csv.NewReader(bufio.NewReader(f))
+		r.FieldsPerRecord = -1 // 列数不一致交给下游的过滤阶段处理
+		for {
+			record, err := r.Read()
+			if err == io.EOF {
+				return
+			}
+
+			var next Result[[]string]
+			var pe *csv.ParseError
+			switch {
+			case errors.As(err, &pe):
+				next = Fail[[]string](&LineError{Path: path, Line: pe.StartLine, Err: pe.Err})
+			case err != nil:
+				send(ctx, out, Fail[[]string](err))
+				return
+			default:
+				next = Ok(record)
+			}
+			if !send(ctx, out, next) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// ReadJSONLines 读取JSON Lines文件，每一行解码为一个T，空行会被跳过
+// 无法解码的行作为 *LineError 发送，读取继续
+func ReadJSONLines[T any](ctx context.Context, path string) <-chan Result[T] {
+	out := make(chan Result[T])
+
+	go func() {
+		defer close(out)
+
+		line := 0
+		for r := range ReadLines(ctx, path) {
+			line++
+			var next Result[T]
+			if r.Err != nil {
+				next.Err = r.Err
+			} else if len(r.Value) == 0 {
+				continue
+			} else if err := json.Unmarshal([]byte(r.Value), &next.Value); err != nil {
+				next.Err = &LineError{Path: path, Line: line, Err: err}
+			}
+			if !send(ctx, out, next) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// atomicFile 先写入同目录下的临时文件，Commit 时重命名为目标文件
+// 写到一半失败或被取消时目标文件保持原样，不会留下半个文件
+type atomicFile struct {
+	path string
+	tmp  *os.File
+	*bufio.Writer
+}
+
+func createAtomic(path string) (*atomicFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{path: path, tmp: tmp, Writer: bufio.NewWriter(tmp)}, nil
+}
+
+// Commit 刷新缓冲区、落盘并重命名，失败时删除临时文件
+func (f *atomicFile) Commit() error {
+	err := f.Flush()
+	if err == nil {
+		err = f.tmp.Sync()
+	}
+	if err == nil {
+		err = f.tmp.Chmod(0o644)
+	}
+	if closeErr := f.tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.tmp.Name(), f.path)
+	}
+	if err != nil {
+		os.Remove(f.tmp.Name())
+	}
+	return err
+}
+
+// Abort 丢弃已写入的内容
+func (f *atomicFile) Abort() {
+	f.tmp.Close()
+	os.Remove(f.tmp.Name())
+}
+
+// encoder 把元素写入w，flush 在所有元素写完后调用一次（可以为nil）
+type encoder[T any] func(w io.Writer) (write func(T) error, flush func() error)
+
+// writeAtomic 把in中的元素逐个编码写入path，返回写入的元素数
+// in关闭后才会提交；ctx取消或写入失败时放弃，目标文件保持原样。
+// 写入失败后仍会读完in，避免上游阻塞在发送上
+func writeAtomic[T any](ctx context.Context, path string, in <-chan T, enc encoder[T]) (int, error) {
+	f, err := createAtomic(path)
+	if err != nil {
+		return 0, err
+	}
+	write, flush := enc(f)
+
+	n := 0
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			break
+		}
+		if err := write(v); err != nil {
+			f.Abort()
+			discard(ctx, in)
+			return n, err
+		}
+		n++
+	}
+
+	// 上游因取消而关闭in时，数据可能不完整
+	if err := ctx.Err(); err != nil {
+		f.Abort()
+		return n, err
+	}
+	if flush != nil {
+		if err := flush(); err != nil {
+			f.Abort()
+			return n, err
+		}
+	}
+	return n, f.Commit()
+}
+
+// discard 读完并丢弃in中剩余的元素，直到in关闭或ctx取消
+func discard[T any](ctx context.Context, in <-chan T) {
+	for {
+		if _, ok := recv(ctx, in); !ok {
+			return
+		}
+	}
+}
+
+// WriteLines 把每个字符串作为一行写入path，in关闭后原子地替换目标文件
+func WriteLines(ctx context.Context, path string, in <-chan string) (int, error) {
+	return writeAtomic(ctx, path, in, func(w io.Writer) (func(string) error, func() error) {
+		return func(s string) error {
+			_, err := io.WriteString(w, s+"\n")
+			return err
+		}, nil
+	})
+}
+
+// WriteCSV 把每条记录写入CSV文件，in关闭后原子地替换目标文件
+func WriteCSV(ctx context.Context, path string, in <-chan []string) (int, error) {
+	return writeAtomic(ctx, path, in, func(w io.Writer) (func([]string) error, func() error) {
+		cw := csv.NewWriter(w)
+		return cw.Write, func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+	})
+}
+
+// WriteJSONLines 把每个元素编码为一行JSON，in关闭后原子地替换目标文件
+func WriteJSONLines[T any](ctx context.Context, path string, in <-chan T) (int, error) {
+	return writeAtomic(ctx, path, in, func(w io.Writer) (func(T) error, func() error) {
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return func(v T) error { return enc.Encode(v) }, nil
+	})
+}
@@ -0,0 +1,188 @@
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFileLinesETL 测试读取、过滤无效行、转换、写入新文件的完整流程
+func TestFileLinesETL(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.txt")
+	out := filepath.Join(dir, "out.txt")
+	os.WriteFile(in, []byte("alpha\n\n# 注释\nbeta\ngamma\n"), 0o644)
+
+	ctx, policy := NewErrorPolicy(context.Background(), FailFast)
+	defer policy.Stop()
+	lines := Handle(ctx, policy, ReadLines(ctx, in))
+	valid := Filter(ctx, lines, func(s string) bool { return s != "" && !strings.HasPrefix(s, "#") })
+	upper := Map(ctx, valid, strings.ToUpper)
+
+	n, err := WriteLines(ctx, out, upper)
+	if err != nil {
+		t.Fatalf("WriteLines 返回错误: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("期望写入3行, 实际 %d", n)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != "ALPHA\nBETA\nGAMMA\n" {
+		t.Errorf("输出内容 %q", data)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// TestReadCSV 测试CSV中格式错误的记录带行号报告，其余记录继续读取
+func TestReadCSV(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.csv")
+	os.WriteFile(in, []byte("name,score\nalice,90\nbob,\"8\"5\ncarol,70\n"), 0o644)
+
+	ctx := context.Background()
+	var records [][]string
+	var errs []error
+	for r := range ReadCSV(ctx, in) {
+		if r.Err != nil {
+			errs = append(errs, r.Err)
+			continue
+		}
+		records = append(records, r.Value)
+	}
+
+	if len(records) != 3 || records[2][0] != "carol" {
+		t.Errorf("记录 %v, 期望表头、alice、carol", records)
+	}
+	var le *LineError
+	if len(errs) != 1 || !errors.As(errs[0], &le) || le.Line != 3 {
+		t.Errorf("期望第3行的 *LineError, 实际 %v", errs)
+	}
+
+	out := filepath.Join(dir, "out.csv")
+	if _, err := WriteCSV(ctx, out, FromSlice(ctx, records)); err != nil {
+		t.Fatalf("WriteCSV 返回错误: %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != "name,score\nalice,90\ncarol,70\n" {
+		t.Errorf("输出内容 %q", data)
+	}
+}
+
+// TestJSONLinesRoundTrip 测试JSON Lines的读取和写入
+func TestJSONLinesRoundTrip(t *testing.T) {
+	type event struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.jsonl")
+	os.WriteFile(in, []byte(`{"id":1,"name":"a"}`+"\n\n"+`{"id":2,`+"\n"+`{"id":3,"name":"<c>"}`+"\n"), 0o644)
+
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+	events := Handle(ctx, policy, ReadJSONLines[event](ctx, in))
+	out := filepath.Join(dir, "out.jsonl")
+	n, err := WriteJSONLines(ctx, out, events)
+	if err != nil || n != 2 {
+		t.Fatalf("WriteJSONLines 返回 %d, %v, 期望写入2条", n, err)
+	}
+
+	report := policy.Report()
+	var le *LineError
+	if report.Failed() != 1 || !errors.As(report.Errors[0], &le) || le.Line != 3 {
+		t.Errorf("期望第3行解码失败, 实际 %v", report.Errors)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != `{"id":1,"name":"a"}`+"\n"+`{"id":3,"name":"<c>"}`+"\n" {
+		t.Errorf("输出内容 %q", data)
+	}
+}
+
+// TestWriteAtomicOnCancel 测试取消时目标文件保持原样且不留下临时文件
+func TestWriteAtomicOnCancel(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.txt")
+	os.WriteFile(out, []byte("旧内容\n"), 0o644)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan string)
+	go func() {
+		in <- "第一行"
+		cancel()
+	}()
+
+	if _, err := WriteLines(ctx, out, in); !errors.Is(err, context.Canceled) {
+		t.Errorf("期望 context.Canceled, 实际 %v", err)
+	}
+	data, _ := os.ReadFile(out)
+	if string(data) != "旧内容\n" {
+		t.Errorf("目标文件被修改: %q", data)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// TestWriteAtomicOnError 测试写入失败时读完输入，上游不会阻塞在发送上
+func TestWriteAtomicOnError(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out.jsonl")
+
+	in := make(chan float64)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(in)
+		for _, v := range []float64{1, math.Inf(1), 3, 4} { // JSON不能编码Inf
+			in <- v
+		}
+	}()
+
+	n, err := WriteJSONLines(context.Background(), out, in)
+	var uerr *json.UnsupportedValueError
+	if n != 1 || !errors.As(err, &uerr) {
+		t.Errorf("期望写入1条后返回 *json.UnsupportedValueError, 实际 %d, %v", n, err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("写入失败后上游仍阻塞在发送上")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("写入失败后不应创建目标文件: %v", err)
+	}
+	assertNoTempFiles(t, dir)
+}
+
+// TestReadLinesTooLong 测试超过 MaxLineSize 的行以错误结束读取
+func TestReadLinesTooLong(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "long.txt")
+	os.WriteFile(in, []byte("短行\n"+strings.Repeat("x", MaxLineSize+1)+"\n"), 0o644)
+
+	var got []Result[string]
+	for r := range ReadLines(context.Background(), in) {
+		got = append(got, r)
+	}
+
+	var le *LineError
+	if len(got) != 2 || got[0].Value != "短行" || !errors.As(got[1].Err, &le) || le.Line != 2 {
+		t.Errorf("期望一行数据和第2行的错误, 实际 %d 个结果, 最后一个错误 %v", len(got), got[len(got)-1].Err)
+	}
+}
+
+// assertNoTempFiles 检查目录中没有残留的临时文件
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp-") {
+			t.Errorf("残留临时文件 %s", e.Name())
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestGraphSplitBroadcastJoin 测试分流、广播旁路输出和合流
+//
+//	数字 → 是否偶数 ─是→ 乘10 → 复制 ─→ 合并 → 结果
+//	                 │               └→ 审计
+//	                 └否→ 取负 ────────↗
+func TestGraphSplitBroadcastJoin(t *testing.T) {
+	g := NewGraph("etl")
+	src := AddSource(g, "数字", func(ctx context.Context) <-chan int { return Generate(ctx, 1, 6) })
+	split := AddSplit(g, "是否偶数", func(x int) bool { return x%2 == 0 })
+	times10 := AddMap(g, "乘10", func(x int) int { return x * 10 })
+	negate := AddMap(g, "取负", func(x int) int { return -x })
+	tee := AddBroadcast[int](g, "复制", 2)
+	join := AddJoin[int](g, "合并", 2)
+
+	var mu sync.Mutex
+	var results, audit []int
+	sink := AddSink(g, "结果", func(x int) { mu.Lock(); results = append(results, x); mu.Unlock() })
+	side := AddSink(g, "审计", func(x int) { mu.Lock(); audit = append(audit, x); mu.Unlock() })
+
+	g.Chain(src, split)
+	g.Connect(split.Out(0), times10.In(0))
+	g.Connect(split.Out(1), negate.In(0))
+	g.Chain(times10, tee)
+	g.Connect(tee.Out(0), join.In(0))
+	g.Connect(tee.Out(1), side.In(0))
+	g.Connect(negate.Out(0), join.In(1))
+	g.Chain(join, sink)
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+
+	sort.Ints(results)
+	if want := []int{-5, -3, -1, 20, 40, 60}; !reflect.DeepEqual(results, want) {
+		t.Errorf("结果 %v, 期望 %v", results, want)
+	}
+	if want := []int{20, 40, 60}; !reflect.DeepEqual(audit, want) {
+		t.Errorf("审计 %v, 期望 %v", audit, want)
+	}
+}
+
+// TestGraphZip 测试两路不同类型的流合并，较长的一路在结束后被取消
+func TestGraphZip(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	g := NewGraph("zip")
+	ids := AddSource(g, "编号", func(ctx context.Context) <-chan int { return Generate(ctx, 1, 1000) })
+	names := AddSource(g, "名字", func(ctx context.Context) <-chan string {
+		return FromSlice(ctx, []string{"a", "b", "c"})
+	})
+	zip := AddZip(g, "配对", func(id int, name string) string { return name + strconv.Itoa(id) })
+	var got []string
+	sink := AddSink(g, "输出", func(s string) { got = append(got, s) })
+
+	g.Connect(ids.Out(0), zip.In(0))
+	g.Connect(names.Out(0), zip.In(1))
+	g.Chain(zip, sink)
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+	if want := []string{"a1", "b2", "c3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("结果 %v, 期望 %v", got, want)
+	}
+	checkGoroutineLeak(t, baseline)
+}
+
+// TestGraphNilInterface 测试元素类型是接口时nil可以经过各类节点
+func TestGraphNilInterface(t *testing.T) {
+	g := NewGraph("errors")
+	src := AddSource(g, "错误", func(ctx context.Context) <-chan error {
+		return FromSlice(ctx, []error{nil, errors.New("失败"), nil})
+	})
+	keep := AddFilter(g, "全部", func(error) bool { return true })
+	split := AddSplit(g, "是否为nil", func(err error) bool { return err == nil })
+	join := AddJoin[error](g, "合并", 2)
+	msg := AddMap(g, "文本", func(err error) string {
+		if err == nil {
+			return "ok"
+		}
+		return err.Error()
+	})
+	var got []string
+	sink := AddSink(g, "输出", func(s string) { got = append(got, s) })
+
+	g.Chain(src, keep, split)
+	g.Connect(split.Out(0), join.In(0))
+	g.Connect(split.Out(1), join.In(1))
+	g.Chain(join, msg, sink)
+
+	if err := g.Run(context.Background()); err != nil {
+		t.Fatalf("Run 返回错误: %v", err)
+	}
+	sort.Strings(got)
+	if want := []string{"ok", "ok", "失败"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("结果 %v, 期望 %v", got, want)
+	}
+}
+
+// TestGraphBuildErrors 测试 Build 一次性报告所有问题
+func TestGraphBuildErrors(t *testing.T) {
+	g := NewGraph("坏拓扑")
+	src := AddSource(g, "数字", func(ctx context.Context) <-chan int { return Generate(ctx, 1, 3) })
+	toStr := AddMap(g, "转字符串", strconv.Itoa)
+	double := AddMap(g, "加倍", func(x int) int { return x * 2 })
+	AddSink(g, "孤立", func(string) {})
+	loopA := AddMap(g, "环A", func(x int) int { return x })
+	loopB := AddMap(g, "环B", func(x int) int { return x })
+	AddFilter(g, "加倍", func(int) bool { return true })
+
+	g.Chain(src, toStr, double)        // string → int 类型不匹配
+	g.Connect(src.Out(0), loopA.In(0)) // 数字的输出被连接两次
+	g.Chain(loopA, loopB, loopA)       // 环，且环A的输入被连接两次
+	g.Connect(src.Out(1), double.In(0))
+
+	err := g.Build()
+	if err == nil {
+		t.Fatal("期望 Build 返回错误")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"节点名 加倍 重复",
+		"转字符串.out0 → 加倍.in0: 类型不匹配, 输出 string, 输入需要 int",
+		"数字.out0 被连接了2次",
+		"环A.in0 被连接了2次",
+		"孤立.in0 未连接",
+		"加倍.out0 未连接",
+		"存在环: 环A → 环B → 环A",
+		"节点 数字 没有输出端口 1",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("错误中缺少 %q:\n%s", want, msg)
+		}
+	}
+
+	if err := g.Run(context.Background()); err == nil {
+		t.Error("检查失败时 Run 不应运行")
+	}
+}
+
+// TestGraphCancel 测试取消后所有节点退出
+func TestGraphCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	g := NewGraph("无限")
+	src := AddSource(g, "无限", func(ctx context.Context) <-chan int { return Generate(ctx, 0, int(^uint(0)>>1)) })
+	tee := AddBroadcast[int](g, "复制", 2)
+	slow := AddSink(g, "慢", func(int) { time.Sleep(time.Millisecond) })
+	fast := AddSink(g, "快", func(int) {})
+	g.Chain(src, tee, slow)
+	g.Connect(tee.Out(1), fast.In(0))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := g.Run(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 DeadlineExceeded, 实际 %v", err)
+	}
+	checkGoroutineLeak(t, baseline)
+}
+
+// TestGraphDOT 测试导出的DOT包含节点、形状和带类型的边
+func TestGraphDOT(t *testing.T) {
+	g := NewGraph("etl")
+	src := AddSource(g, "读取", func(ctx context.Context) <-chan string { return nil })
+	split := AddSplit(g, "有效", func(s string) bool { return s != "" })
+	ok := AddSink(g, "写入", func(string) {})
+	bad := AddSink(g, "丢弃", func(string) {})
+	g.Chain(src, split, ok)
+	g.Connect(split.Out(1), bad.In(0))
+
+	dot := g.DOT()
+	for _, want := range []string{
+		`digraph "etl" {`,
+		`n0 [label="读取\n(source)" shape=invhouse];`,
+		`n1 [label="有效\n(split)" shape=diamond];`,
+		`n0 -> n1 [label="string"];`,
+		`n1 -> n2 [label="是: string"];`,
+		`n1 -> n3 [label="否: string"];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT中缺少 %q:\n%s", want, dot)
+		}
+	}
+}
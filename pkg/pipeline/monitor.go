@@ -0,0 +1,316 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+// Monitor 收集一条Pipeline中各个阶段的运行指标
+//
+// 每个阶段记录输入输出数量、错误数、处理耗时、等待输入和等待下游的耗时，
+// 以及输出Channel的当前占用。通过这些数据可以看出哪个阶段是瓶颈：
+// 瓶颈阶段几乎一直在处理，它的上游阻塞在发送上，下游阻塞在接收上。
+//
+// 库内的阶段通过 WithStage 接入，Configured 通过 WithMonitor 接入。
+type Monitor struct {
+	start time.Time
+
+	mu     sync.Mutex
+	stages []*StageMonitor
+	byName map[string]*StageMonitor
+}
+
+// NewMonitor 创建一个空的监控器
+func NewMonitor() *Monitor {
+	return &Monitor{
+		start:  time.Now(),
+		byName: make(map[string]*StageMonitor),
+	}
+}
+
+// Stage 返回名为name的阶段监控，不存在时按调用顺序创建
+func (m *Monitor) Stage(name string) *StageMonitor {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.byName[name]; ok {
+		return s
+	}
+	s := &StageMonitor{name: name}
+	m.stages = append(m.stages, s)
+	m.byName[name] = s
+	return s
+}
+
+// StageMonitor 记录单个阶段的指标，nil值的所有方法都是空操作
+type StageMonitor struct {
+	name string
+
+	in       atomic.Int64
+	out      atomic.Int64
+	errors   atomic.Int64
+	busy     atomic.Int64 // 纳秒
+	recvWait atomic.Int64
+	sendWait atomic.Int64
+	workers  atomic.Int64
+
+	mu       sync.Mutex
+	queueLen func() int // 输出Channel的当前长度
+	queueCap int
+}
+
+// stageKey 是ctx中 StageMonitor 的键
+type stageKey struct{}
+
+// WithStage 返回携带s的ctx，用它创建的库内阶段会把指标记录到s，
+// 包括 Map、Filter、FlatMap、Batch、Tap、TryMap、Resilient、Throttle 和 TryThrottle：
+//
+//	m := pipeline.NewMonitor()
+//	parsed := pipeline.TryMap(pipeline.WithStage(ctx, m.Stage("解析")), "解析", lines, parse)
+//	kept := pipeline.Filter(pipeline.WithStage(ctx, m.Stage("过滤")), parsed, valid)
+//
+// 每个阶段应当使用各自的ctx，否则它们的指标会记到同一个 StageMonitor 上。
+// Result 流上的阶段只把本阶段产生的错误计入 Errors，上游传来的错误不算
+func WithStage(ctx context.Context, s *StageMonitor) context.Context {
+	return context.WithValue(ctx, stageKey{}, s)
+}
+
+// stageOf 返回ctx携带的 StageMonitor，没有时返回nil
+func stageOf(ctx context.Context) *StageMonitor {
+	s, _ := ctx.Value(stageKey{}).(*StageMonitor)
+	return s
+}
+
+// watchOutput 登记要观察占用情况的输出Channel
+func watchOutput[T any](s *StageMonitor, out chan T) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.queueLen = func() int { return len(out) }
+	s.queueCap = cap(out)
+	s.mu.Unlock()
+}
+
+// monitoredRecv 与recv相同，额外记录等待输入的时间
+func monitoredRecv[T any](ctx context.Context, s *StageMonitor, in <-chan T) (T, bool) {
+	if s == nil {
+		return recv(ctx, in)
+	}
+	start := time.Now()
+	v, ok := recv(ctx, in)
+	s.recvWait.Add(int64(time.Since(start)))
+	if ok {
+		s.in.Add(1)
+	}
+	return v, ok
+}
+
+// monitoredSend 与send相同，额外记录等待下游的时间
+func monitoredSend[T any](ctx context.Context, s *StageMonitor, out chan<- T, v T) bool {
+	if s == nil {
+		return send(ctx, out, v)
+	}
+	start := time.Now()
+	ok := send(ctx, out, v)
+	s.sendWait.Add(int64(time.Since(start)))
+	if ok {
+		s.out.Add(1)
+	}
+	return ok
+}
+
+// received 记录一次在select中等到的输入，用于不能直接使用 monitoredRecv 的阶段
+func (s *StageMonitor) received(start time.Time) {
+	if s == nil {
+		return
+	}
+	s.recvWait.Add(int64(time.Since(start)))
+	s.in.Add(1)
+}
+
+// now 返回开始处理的时间，s为nil时不读取时钟
+func (s *StageMonitor) now() time.Time {
+	if s == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// observe 记录一次处理的耗时和是否出错
+func (s *StageMonitor) observe(start time.Time, err error) {
+	if s == nil {
+		return
+	}
+	s.busy.Add(int64(time.Since(start)))
+	if err != nil {
+		s.errors.Add(1)
+	}
+}
+
+// workerStarted / workerStopped 记录并行goroutine数量
+func (s *StageMonitor) workerStarted() {
+	if s != nil {
+		s.workers.Add(1)
+	}
+}
+
+func (s *StageMonitor) workerStopped() {
+	if s != nil {
+		s.workers.Add(-1)
+	}
+}
+
+// MonitoredMap 与 Map 相同，但把指标记录到s，输出Channel的缓冲区大小为buffer
+func MonitoredMap[T, U any](ctx context.Context, s *StageMonitor, in <-chan T, buffer int, fn func(T) U) <-chan U {
+	out := make(chan U, buffer)
+	watchOutput(s, out)
+
+	go func() {
+		defer close(out)
+		s.workerStarted()
+		defer s.workerStopped()
+
+		for {
+			v, ok := monitoredRecv(ctx, s, in)
+			if !ok {
+				return
+			}
+			start := time.Now()
+			u := fn(v)
+			s.observe(start, nil)
+			if !monitoredSend(ctx, s, out, u) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// StageSnapshot 是一个阶段某一时刻的指标
+type StageSnapshot struct {
+	Name     string
+	In       int64
+	Out      int64
+	Errors   int64
+	Workers  int64
+	Busy     time.Duration // 处理元素的累计耗时（所有goroutine之和）
+	RecvWait time.Duration // 等待上游的累计耗时
+	SendWait time.Duration // 等待下游的累计耗时
+	Queue    int           // 输出Channel中的元素数
+	Capacity int           // 输出Channel的容量
+
+	Throughput float64 // 每秒输出的元素数（从监控开始算起）
+}
+
+// Utilization 返回处理耗时占总耗时的比例，越接近1越可能是瓶颈
+func (s StageSnapshot) Utilization() float64 {
+	total := s.Busy + s.RecvWait + s.SendWait
+	if total <= 0 {
+		return 0
+	}
+	return float64(s.Busy) / float64(total)
+}
+
+// Snapshot 返回所有阶段的指标，按登记顺序排列
+func (m *Monitor) Snapshot() []StageSnapshot {
+	m.mu.Lock()
+	stages := append([]*StageMonitor(nil), m.stages...)
+	m.mu.Unlock()
+
+	elapsed := time.Since(m.start).Seconds()
+	snaps := make([]StageSnapshot, len(stages))
+	for i, s := range stages {
+		s.mu.Lock()
+		queue, capacity := 0, s.queueCap
+		if s.queueLen != nil {
+			queue = s.queueLen()
+		}
+		s.mu.Unlock()
+
+		out := s.out.Load()
+		snaps[i] = StageSnapshot{
+			Name:     s.name,
+			In:       s.in.Load(),
+			Out:      out,
+			Errors:   s.errors.Load(),
+			Workers:  s.workers.Load(),
+			Busy:     time.Duration(s.busy.Load()),
+			RecvWait: time.Duration(s.recvWait.Load()),
+			SendWait: time.Duration(s.sendWait.Load()),
+			Queue:    queue,
+			Capacity: capacity,
+		}
+		if elapsed > 0 {
+			snaps[i].Throughput = float64(out) / elapsed
+		}
+	}
+	return snaps
+}
+
+// Bottleneck 返回利用率最高的阶段名，没有数据时返回空字符串
+func Bottleneck(snaps []StageSnapshot) string {
+	best, name := 0.0, ""
+	for _, s := range snaps {
+		if u := s.Utilization(); u > best {
+			best, name = u, s.Name
+		}
+	}
+	return name
+}
+
+// WriteTable 把当前指标以表格形式写入w，瓶颈阶段会被标记出来
+func (m *Monitor) WriteTable(w io.Writer) error {
+	snaps := m.Snapshot()
+	bottleneck := Bottleneck(snaps)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "阶段\t输入\t输出\t错误\t吞吐/秒\t处理\t等上游\t等下游\t利用率\t缓冲区\t")
+	for _, s := range snaps {
+		mark := ""
+		if s.Name == bottleneck {
+			mark = "◀ 瓶颈"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.1f\t%v\t%v\t%v\t%.0f%%\t%s\t%s\n",
+			s.Name, s.In, s.Out, s.Errors, s.Throughput,
+			s.Busy.Round(time.Millisecond), s.RecvWait.Round(time.Millisecond), s.SendWait.Round(time.Millisecond),
+			s.Utilization()*100, occupancyBar(s.Queue, s.Capacity), mark)
+	}
+	return tw.Flush()
+}
+
+// occupancyBar 用字符条显示缓冲区占用，例如 [###.......] 3/10
+func occupancyBar(n, capacity int) string {
+	if capacity == 0 {
+		return "非缓冲"
+	}
+	const width = 10
+	filled := n * width / capacity
+	return fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat(".", width-filled), n, capacity)
+}
+
+// Dashboard 每隔interval刷新一次终端表格，直到ctx取消
+// 使用ANSI转义序列清屏，适合直接输出到终端
+func (m *Monitor) Dashboard(ctx context.Context, w io.Writer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fmt.Fprint(w, "\033[H\033[2J")
+		fmt.Fprintf(w, "Pipeline监控  运行时间 %v\n\n", time.Since(m.start).Round(time.Second))
+		m.WriteTable(w)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMonitorFindsBottleneck 测试慢阶段被识别为瓶颈，上下游分别阻塞在发送和接收上
+func TestMonitorFindsBottleneck(t *testing.T) {
+	const n = 50
+	ctx := context.Background()
+	m := NewMonitor()
+
+	fast1 := MonitoredMap(ctx, m.Stage("快1"), Generate(ctx, 1, n), 0, func(x int) int { return x })
+	slow := MonitoredMap(ctx, m.Stage("慢"), fast1, 0, func(x int) int {
+		time.Sleep(2 * time.Millisecond)
+		return x
+	})
+	fast2 := MonitoredMap(ctx, m.Stage("快2"), slow, 4, func(x int) int { return x })
+
+	if got := len(Collect(ctx, fast2)); got != n {
+		t.Fatalf("期望 %d 个结果, 实际 %d", n, got)
+	}
+
+	snaps := m.Snapshot()
+	if len(snaps) != 3 || snaps[0].Name != "快1" || snaps[2].Name != "快2" {
+		t.Fatalf("快照应按登记顺序排列: %+v", snaps)
+	}
+	for _, s := range snaps {
+		if s.In != n || s.Out != n || s.Errors != 0 {
+			t.Errorf("%s: 输入 %d 输出 %d 错误 %d, 期望 %d/%d/0", s.Name, s.In, s.Out, s.Errors, n, n)
+		}
+		if s.Workers != 0 {
+			t.Errorf("%s: 结束后仍有 %d 个goroutine", s.Name, s.Workers)
+		}
+	}
+	if snaps[2].Capacity != 4 {
+		t.Errorf("快2 的缓冲区容量应为4, 实际 %d", snaps[2].Capacity)
+	}
+
+	if got := Bottleneck(snaps); got != "慢" {
+		t.Errorf("瓶颈应为 慢, 实际 %q", got)
+	}
+	if snaps[0].SendWait < snaps[0].Busy {
+		t.Errorf("慢阶段的上游应主要阻塞在发送上: %+v", snaps[0])
+	}
+	if snaps[2].RecvWait < snaps[2].Busy {
+		t.Errorf("慢阶段的下游应主要阻塞在接收上: %+v", snaps[2])
+	}
+
+	var buf bytes.Buffer
+	m.WriteTable(&buf)
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.Contains(line, "瓶颈") && !strings.HasPrefix(line, "慢") {
+			t.Errorf("瓶颈标记在错误的行上: %q", line)
+		}
+	}
+	if !strings.Contains(buf.String(), "◀ 瓶颈") {
+		t.Errorf("表格中没有瓶颈标记:\n%s", buf.String())
+	}
+}
+
+// TestConfiguredWithMonitor 测试配置构建的Pipeline记录每个阶段的错误数和缓冲区
+func TestConfiguredWithMonitor(t *testing.T) {
+	reg := IntRegistry()
+	reg.Register("拒绝负数", func(Params) (StageFunc[int], error) {
+		return func(x int) (int, error) {
+			if x < 0 {
+				return 0, os.ErrInvalid
+			}
+			return x, nil
+		}, nil
+	})
+
+	cfg := &Config{Stages: []StageConfig{
+		{Stage: "加", Params: Params{"n": -4}},
+		{Stage: "拒绝负数", Parallelism: 2, Buffer: 8},
+		{Stage: "加", Params: Params{"n": 1}},
+	}}
+	p, err := Build(cfg, reg)
+	if err != nil {
+		t.Fatalf("Build 返回错误: %v", err)
+	}
+	m := NewMonitor()
+	p.WithMonitor(m)
+
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+	got := Collect(ctx, Handle(ctx, policy, p.Run(ctx, Generate(ctx, 1, 10))))
+	if len(got) != 7 {
+		t.Errorf("结果 %v, 期望7个", got)
+	}
+
+	snaps := m.Snapshot()
+	if len(snaps) != 3 || snaps[0].Name != "1.加" || snaps[2].Name != "3.加" {
+		t.Fatalf("阶段名应带序号: %+v", snaps)
+	}
+	// 失败的元素仍以 Result 的形式向下游传递，所以每个阶段都输入输出10个
+	for _, s := range snaps {
+		if s.In != 10 || s.Out != 10 {
+			t.Errorf("%s: 输入 %d 输出 %d, 期望 10/10", s.Name, s.In, s.Out)
+		}
+	}
+	if snaps[1].Errors != 3 {
+		t.Errorf("拒绝负数 阶段应有3个错误, 实际 %d", snaps[1].Errors)
+	}
+	if snaps[0].Errors != 0 || snaps[2].Errors != 0 {
+		t.Errorf("其他阶段不应有错误: %+v", snaps)
+	}
+	if snaps[1].Capacity != 8 {
+		t.Errorf("拒绝负数 阶段的缓冲区容量应为8, 实际 %d", snaps[1].Capacity)
+	}
+}
+
+// TestWithStage 测试库内阶段通过 WithStage 记录指标，错误只计入产生它的阶段
+func TestWithStage(t *testing.T) {
+	ctx := context.Background()
+	m := NewMonitor()
+	stage := func(name string) context.Context { return WithStage(ctx, m.Stage(name)) }
+
+	evens := Filter(stage("过滤"), Generate(ctx, 1, 10), func(x int) bool { return x%2 == 0 })
+	parsed := TryMap(stage("检查"), "检查", Lift(ctx, evens), func(x int) (int, error) {
+		if x == 4 {
+			return 0, os.ErrInvalid
+		}
+		return x, nil
+	})
+	limited := TryThrottle(stage("限流"), "限流", parsed, &rejectEvery{n: 3})
+	batches := Batch(stage("攒批"), limited, 2, 0)
+
+	if got := len(Collect(ctx, batches)); got != 3 {
+		t.Fatalf("期望3批, 实际 %d", got)
+	}
+
+	want := map[string][3]int64{ // 输入、输出、错误
+		"过滤": {10, 5, 0},
+		"检查": {5, 5, 1},
+		"限流": {5, 5, 1}, // 上游的错误直接传递，第3个正常元素被拒绝
+		"攒批": {5, 3, 0},
+	}
+	for _, s := range m.Snapshot() {
+		w := want[s.Name]
+		if s.In != w[0] || s.Out != w[1] || s.Errors != w[2] {
+			t.Errorf("%s: 输入 %d 输出 %d 错误 %d, 期望 %v", s.Name, s.In, s.Out, s.Errors, w)
+		}
+		if s.Workers != 0 {
+			t.Errorf("%s: 结束后仍有 %d 个goroutine", s.Name, s.Workers)
+		}
+	}
+}
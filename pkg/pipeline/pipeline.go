@@ -0,0 +1,307 @@
+// Package pipeline 提供泛型的Pipeline阶段函数。
+//
+// week1/day2 的 pipeline_pattern.go 中 processStage、square、cube 等函数
+// 重复着同样的模板：启动一个goroutine，从输入Channel读取，处理后写入输出Channel，
+// 结束时关闭输出Channel。这个包把这段模板抽成泛型阶段，并且每个阶段都接收
+// context.Context：context取消后所有阶段都会退出，不会泄漏goroutine。
+//
+//	numbers := pipeline.FromSlice(ctx, []int{1, 2, 3, 4, 5})
+//	squared := pipeline.Map(ctx, numbers, func(x int) int { return x * x })
+//	evens := pipeline.Filter(ctx, squared, func(x int) bool { return x%2 == 0 })
+//	for v := range evens {
+//		fmt.Println(v)
+//	}
+//
+// 可能出错的处理使用 Result 流：TryMap 把错误放进流中向下传递，
+// Handle 按 ErrorPolicy 决定快速失败、跳过并汇总，还是发送到死信Channel。
+// 所有 Handle 创建完后调用 ErrorPolicy.Close，Pipeline结束后调用 Stop。
+//
+// Monitor 记录每个阶段的吞吐、错误、处理和阻塞耗时以及缓冲区占用，
+// 用来找出拖慢整条Pipeline的阶段；用 WithStage 创建的ctx构建阶段即可接入。
+//
+// Resilient 为不稳定的阶段提供重试、备用处理和熔断，最终失败的元素
+// 带着完整的失败历史进入 Result 流，可以交给 DeadLetter 模式处理。
+//
+// ReadLines、ReadCSV、ReadJSONLines 以流的方式读取文件，WriteLines、WriteCSV、
+// WriteJSONLines 先写临时文件再重命名，取消或出错时不会留下写了一半的文件。
+//
+// FanOut 按轮询、最少负载、按key哈希或广播把一个流分给多个消费者，
+// Merge 和 MergeSorted 把多个流合并为一个，它们都可以通过ctx取消。
+//
+// Graph 用于搭建分流、合流、旁路输出这类非线性的拓扑，运行前检查类型和环，
+// 并可以导出为Graphviz DOT。
+//
+// Windowed 按滚动、滑动或会话窗口分组聚合，支持事件时间和水位线，
+// 用于"每秒总和"、"每分钟前N名"这类实时指标。
+package pipeline
+
+import (
+	"context"
+	"time"
+)
+
+// send 把v发送到out，ctx取消时放弃并返回false
+func send[T any](ctx context.Context, out chan<- T, v T) bool {
+	select {
+	case out <- v:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// recv 从in读取一个值，in关闭或ctx取消时ok为false
+func recv[T any](ctx context.Context, in <-chan T) (v T, ok bool) {
+	select {
+	case v, ok = <-in:
+		return v, ok
+	case <-ctx.Done():
+		return v, false
+	}
+}
+
+// FromSlice 把切片中的元素依次发送到返回的Channel
+func FromSlice[T any](ctx context.Context, items []T) <-chan T {
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			if !send(ctx, out, item) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Generate 依次发送 [start, end] 范围内的整数，对应 generateNumbers
+func Generate(ctx context.Context, start, end int) <-chan int {
+	out := make(chan int)
+
+	go func() {
+		defer close(out)
+		for i := start; i <= end; i++ {
+			if !send(ctx, out, i) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Map 对每个元素调用fn，输出fn的返回值
+func Map[T, U any](ctx context.Context, in <-chan T, fn func(T) U) <-chan U {
+	out := make(chan U)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			v, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			start := sm.now()
+			u := fn(v)
+			sm.observe(start, nil)
+			if !monitoredSend(ctx, sm, out, u) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Filter 只输出keep返回true的元素
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			v, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			start := sm.now()
+			kept := keep(v)
+			sm.observe(start, nil)
+			if kept && !monitoredSend(ctx, sm, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// FlatMap 对每个元素调用fn，把返回的切片逐个输出
+func FlatMap[T, U any](ctx context.Context, in <-chan T, fn func(T) []U) <-chan U {
+	out := make(chan U)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			v, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			start := sm.now()
+			us := fn(v)
+			sm.observe(start, nil)
+			for _, u := range us {
+				if !monitoredSend(ctx, sm, out, u) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Reduce 用fn把所有元素累积到initial上，输入结束后输出唯一的累积结果
+// ctx取消时不输出任何值
+func Reduce[T, U any](ctx context.Context, in <-chan T, initial U, fn func(U, T) U) <-chan U {
+	out := make(chan U, 1)
+
+	go func() {
+		defer close(out)
+		acc := initial
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				break
+			}
+			acc = fn(acc, v)
+		}
+		if ctx.Err() == nil {
+			out <- acc
+		}
+	}()
+
+	return out
+}
+
+// Batch 把元素按size个一组输出
+// maxWait大于0时，攒批超过maxWait也会输出一个不满的批次；输入结束时输出剩余元素
+func Batch[T any](ctx context.Context, in <-chan T, size int, maxWait time.Duration) <-chan []T {
+	if size < 1 {
+		size = 1
+	}
+	out := make(chan []T)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+
+		var (
+			batch []T
+			timer *time.Timer
+			timeC <-chan time.Time // 没有攒批时为nil，select永远不会选中
+		)
+		stopTimer := func() {
+			if timer != nil {
+				timer.Stop()
+				timer, timeC = nil, nil
+			}
+		}
+		defer stopTimer()
+
+		flush := func() bool {
+			stopTimer()
+			if len(batch) == 0 {
+				return true
+			}
+			b := batch
+			batch = nil
+			return monitoredSend(ctx, sm, out, b)
+		}
+
+		for {
+			start := sm.now()
+			select {
+			case <-ctx.Done():
+				return
+			case <-timeC:
+				if !flush() {
+					return
+				}
+			case v, ok := <-in:
+				if !ok {
+					flush()
+					return
+				}
+				sm.received(start)
+				batch = append(batch, v)
+				if len(batch) == 1 && maxWait > 0 {
+					timer = time.NewTimer(maxWait)
+					timeC = timer.C
+				}
+				if len(batch) >= size && !flush() {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Tap 对每个元素调用fn（例如打印日志），然后原样输出
+// 相当于 processStage 中的 fmt.Printf
+func Tap[T any](ctx context.Context, in <-chan T, fn func(T)) <-chan T {
+	out := make(chan T)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			v, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			start := sm.now()
+			fn(v)
+			sm.observe(start, nil)
+			if !monitoredSend(ctx, sm, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Collect 读取in中的所有元素并返回切片，ctx取消时返回已读取的部分
+func Collect[T any](ctx context.Context, in <-chan T) []T {
+	var items []T
+	for {
+		v, ok := recv(ctx, in)
+		if !ok {
+			return items
+		}
+		items = append(items, v)
+	}
+}
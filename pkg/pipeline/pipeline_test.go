@@ -0,0 +1,163 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// checkGoroutineLeak 等待goroutine数量回落到基线，超时则报告泄漏
+func checkGoroutineLeak(t *testing.T, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	buf := make([]byte, 1<<16)
+	n := runtime.Stack(buf, true)
+	t.Errorf("goroutine泄漏: 基线 %d, 当前 %d\n%s", baseline, runtime.NumGoroutine(), buf[:n])
+}
+
+// TestMultiStage 测试 multiStagePipeline 的场景：平方 → 加10 → 乘以2
+func TestMultiStage(t *testing.T) {
+	ctx := context.Background()
+
+	numbers := Generate(ctx, 1, 5)
+	stage1 := Map(ctx, numbers, func(x int) int { return x * x })
+	stage2 := Map(ctx, stage1, func(x int) int { return x + 10 })
+	stage3 := Map(ctx, stage2, func(x int) int { return x * 2 })
+
+	got := Collect(ctx, stage3)
+	want := []int{22, 28, 38, 52, 70}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("结果 %v, 期望 %v", got, want)
+	}
+}
+
+// TestStages 表格驱动测试各个阶段
+func TestStages(t *testing.T) {
+	ctx := context.Background()
+	src := func() <-chan int { return FromSlice(ctx, []int{1, 2, 3, 4, 5, 6}) }
+
+	tests := []struct {
+		name string
+		run  func() any
+		want any
+	}{
+		{
+			name: "Map改变类型",
+			run: func() any {
+				return Collect(ctx, Map(ctx, src(), func(x int) string { return strings.Repeat("*", x) }))
+			},
+			want: []string{"*", "**", "***", "****", "*****", "******"},
+		},
+		{
+			name: "Filter",
+			run: func() any {
+				return Collect(ctx, Filter(ctx, src(), func(x int) bool { return x%2 == 0 }))
+			},
+			want: []int{2, 4, 6},
+		},
+		{
+			name: "FlatMap",
+			run: func() any {
+				return Collect(ctx, FlatMap(ctx, src(), func(x int) []int {
+					if x > 2 {
+						return nil
+					}
+					return []int{x, x * 10}
+				}))
+			},
+			want: []int{1, 10, 2, 20},
+		},
+		{
+			name: "Reduce",
+			run: func() any {
+				return Collect(ctx, Reduce(ctx, src(), 0, func(acc, x int) int { return acc + x }))
+			},
+			want: []int{21},
+		},
+		{
+			name: "Batch按大小",
+			run: func() any {
+				return Collect(ctx, Batch(ctx, src(), 4, 0))
+			},
+			want: [][]int{{1, 2, 3, 4}, {5, 6}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.run(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("结果 %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTap 测试Tap看到每个元素且不改变数据
+func TestTap(t *testing.T) {
+	ctx := context.Background()
+
+	var seen []int
+	got := Collect(ctx, Tap(ctx, Generate(ctx, 1, 3), func(x int) { seen = append(seen, x) }))
+
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) || !reflect.DeepEqual(seen, []int{1, 2, 3}) {
+		t.Errorf("输出 %v, Tap看到 %v", got, seen)
+	}
+}
+
+// TestBatchMaxWait 测试输入很慢时按时间输出不满的批次
+func TestBatchMaxWait(t *testing.T) {
+	ctx := context.Background()
+	in := make(chan int)
+	batches := Batch(ctx, in, 10, 20*time.Millisecond)
+
+	go func() {
+		in <- 1
+		in <- 2
+		time.Sleep(60 * time.Millisecond)
+		in <- 3
+		close(in)
+	}()
+
+	got := Collect(ctx, batches)
+	want := [][]int{{1, 2}, {3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("批次 %v, 期望 %v", got, want)
+	}
+}
+
+// TestCancelTearsDown 测试取消后整条Pipeline退出，不泄漏goroutine
+func TestCancelTearsDown(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// 无限数据源
+	src := Generate(ctx, 0, int(^uint(0)>>1))
+	squared := Map(ctx, src, func(x int) int { return x * x })
+	evens := Filter(ctx, squared, func(x int) bool { return x%2 == 0 })
+	pairs := FlatMap(ctx, evens, func(x int) []int { return []int{x, x} })
+	tapped := Tap(ctx, pairs, func(int) {})
+	batches := Batch(ctx, tapped, 3, time.Millisecond)
+	sum := Reduce(ctx, Map(ctx, batches, func(b []int) int { return len(b) }), 0,
+		func(acc, n int) int { return acc + n })
+
+	// 数据源无限，Reduce永远等不到输入结束，只能靠取消退出
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if _, ok := <-sum; ok {
+		t.Error("取消后 Reduce 不应该输出结果")
+	}
+	checkGoroutineLeak(t, baseline)
+}
@@ -0,0 +1,131 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Params 是配置文件中某个阶段的参数
+type Params map[string]any
+
+// Int 读取整数参数，缺省时返回def
+// JSON解码得到的数字是float64，YAML得到的是int，这里统一处理
+func (p Params) Int(key string, def int) (int, error) {
+	v, ok := p[key]
+	if !ok {
+		return def, nil
+	}
+
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		if n != float64(int(n)) {
+			return 0, fmt.Errorf("参数 %s 必须是整数, 实际 %v", key, n)
+		}
+		return int(n), nil
+	default:
+		return 0, fmt.Errorf("参数 %s 必须是整数, 实际 %T", key, v)
+	}
+}
+
+// StageFunc 是一个可能失败的处理函数
+type StageFunc[T any] func(T) (T, error)
+
+// Factory 根据参数创建处理函数，参数不合法时返回错误
+type Factory[T any] func(params Params) (StageFunc[T], error)
+
+// Registry 按名字登记可用的处理阶段，对应 dynamicPipeline 中的 processors 列表
+type Registry[T any] struct {
+	mu        sync.RWMutex
+	factories map[string]Factory[T]
+}
+
+// NewRegistry 创建一个空的登记表
+func NewRegistry[T any]() *Registry[T] {
+	return &Registry[T]{factories: make(map[string]Factory[T])}
+}
+
+// Register 登记一个带参数的阶段，名字重复时返回错误
+func (r *Registry[T]) Register(name string, factory Factory[T]) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.factories[name]; exists {
+		return fmt.Errorf("阶段 %q 已经登记过", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// RegisterFunc 登记一个不需要参数的阶段
+func (r *Registry[T]) RegisterFunc(name string, fn func(T) T) error {
+	return r.Register(name, func(Params) (StageFunc[T], error) {
+		return func(v T) (T, error) { return fn(v), nil }, nil
+	})
+}
+
+// Lookup 按名字查找阶段
+func (r *Registry[T]) Lookup(name string) (Factory[T], bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, ok := r.factories[name]
+	return f, ok
+}
+
+// Names 返回所有已登记的阶段名，按字典序排列
+func (r *Registry[T]) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IntRegistry 返回登记了内置整数阶段的登记表
+//
+// 无参数阶段来自 dynamicPipeline：加倍、平方、加5、乘以3；
+// 带参数阶段：加(n)、乘(n)、除(n，n不能为0)
+func IntRegistry() *Registry[int] {
+	r := NewRegistry[int]()
+
+	r.RegisterFunc("加倍", func(x int) int { return x * 2 })
+	r.RegisterFunc("平方", func(x int) int { return x * x })
+	r.RegisterFunc("加5", func(x int) int { return x + 5 })
+	r.RegisterFunc("乘以3", func(x int) int { return x * 3 })
+
+	r.Register("加", func(p Params) (StageFunc[int], error) {
+		n, err := p.Int("n", 0)
+		if err != nil {
+			return nil, err
+		}
+		return func(x int) (int, error) { return x + n, nil }, nil
+	})
+	r.Register("乘", func(p Params) (StageFunc[int], error) {
+		n, err := p.Int("n", 1)
+		if err != nil {
+			return nil, err
+		}
+		return func(x int) (int, error) { return x * n, nil }, nil
+	})
+	r.Register("除", func(p Params) (StageFunc[int], error) {
+		n, err := p.Int("n", 1)
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, fmt.Errorf("参数 n 不能为0")
+		}
+		return func(x int) (int, error) { return x / n, nil }, nil
+	})
+
+	return r
+}
@@ -0,0 +1,289 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Result 是带错误的流元素，替代 generateNumbersWithError 中用 interface{}
+// 混装数值和错误的做法：调用方不再需要类型断言，也不会漏掉未知类型
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Ok 构造一个成功的 Result
+func Ok[T any](v T) Result[T] {
+	return Result[T]{Value: v}
+}
+
+// Fail 构造一个失败的 Result
+func Fail[T any](err error) Result[T] {
+	return Result[T]{Err: err}
+}
+
+// StageError 记录在哪个阶段、处理哪个输入时出错
+type StageError struct {
+	Stage string
+	Input any
+	Err   error
+}
+
+func (e *StageError) Error() string {
+	return fmt.Sprintf("阶段 %s 处理 %v 失败: %v", e.Stage, e.Input, e.Err)
+}
+
+func (e *StageError) Unwrap() error {
+	return e.Err
+}
+
+// Lift 把普通Channel转换为 Result 流
+func Lift[T any](ctx context.Context, in <-chan T) <-chan Result[T] {
+	return Map(ctx, in, Ok[T])
+}
+
+// TryMap 对成功的元素调用fn，fn返回的错误包装成 *StageError 放入流中
+// 上游的错误原样向下传递，不会调用fn
+func TryMap[T, U any](ctx context.Context, stage string, in <-chan Result[T], fn func(T) (U, error)) <-chan Result[U] {
+	out := make(chan Result[U])
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			r, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+
+			var next Result[U]
+			if r.Err != nil {
+				next.Err = r.Err
+			} else {
+				start := sm.now()
+				v, err := fn(r.Value)
+				sm.observe(start, err)
+				if err != nil {
+					next.Err = &StageError{Stage: stage, Input: r.Value, Err: err}
+				} else {
+					next.Value = v
+				}
+			}
+
+			if !monitoredSend(ctx, sm, out, next) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// ErrorMode 决定 Handle 遇到错误时怎么做
+type ErrorMode int
+
+const (
+	// FailFast 遇到第一个错误就取消整条Pipeline
+	FailFast ErrorMode = iota
+	// SkipAndCollect 跳过出错的元素，错误记入报告，处理继续
+	SkipAndCollect
+	// DeadLetter 出错的元素发送到死信Channel，错误记入报告，处理继续
+	DeadLetter
+)
+
+func (m ErrorMode) String() string {
+	switch m {
+	case FailFast:
+		return "fail-fast"
+	case SkipAndCollect:
+		return "skip-and-collect"
+	case DeadLetter:
+		return "dead-letter"
+	default:
+		return fmt.Sprintf("ErrorMode(%d)", int(m))
+	}
+}
+
+// deadLetterBuffer 死信Channel的缓冲区大小
+const deadLetterBuffer = 16
+
+// ErrorPolicy 在一条Pipeline的所有 Handle 阶段之间共享错误处理方式和错误记录
+//
+// 使用顺序：NewErrorPolicy 之后 defer Stop；创建完所有 Handle 阶段后调用 Close，
+// 这样死信Channel才能在所有 Handle 结束后被关闭。
+type ErrorPolicy struct {
+	mode   ErrorMode
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	errs    []error
+	aborted bool
+	active  int  // 还在运行的 Handle 数量
+	closed  bool // 已经调用过 Close，不能再创建 Handle
+
+	dead chan error
+}
+
+// NewErrorPolicy 创建错误处理策略，返回的ctx应当传给这条Pipeline的所有阶段，
+// FailFast 模式下遇到错误时会取消它；Pipeline结束后调用 Stop 释放它
+func NewErrorPolicy(ctx context.Context, mode ErrorMode) (context.Context, *ErrorPolicy) {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &ErrorPolicy{
+		mode:   mode,
+		cancel: cancel,
+		dead:   make(chan error, deadLetterBuffer),
+	}
+	return ctx, p
+}
+
+// DeadLetters 返回死信Channel，只在 DeadLetter 模式下有数据
+// 元素是出错的 Result.Err（通常是 *StageError，其中带有原始输入）
+// 调用 Close 且所有 Handle 阶段结束后该Channel被关闭；DeadLetter 模式下调用方必须读取它，
+// 否则 Handle 会在缓冲区满后阻塞
+func (p *ErrorPolicy) DeadLetters() <-chan error {
+	return p.dead
+}
+
+// Close 表示这条Pipeline的 Handle 阶段已经全部创建，之后再调用 Handle 会panic
+// 所有 Handle 结束后死信Channel被关闭。可以多次调用
+func (p *ErrorPolicy) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+	p.closed = true
+	p.closeDeadIfDone()
+}
+
+// Stop 取消 NewErrorPolicy 返回的ctx，释放它占用的资源
+// 应当在Pipeline的输出读完之后调用，通常用 defer；提前调用会中止整条Pipeline
+func (p *ErrorPolicy) Stop() {
+	p.cancel()
+}
+
+// closeDeadIfDone 在 Close 之后且没有运行中的 Handle 时关闭死信Channel，调用方必须持有锁
+// Close 之后 active 只会减少，所以这个条件只会成立一次
+func (p *ErrorPolicy) closeDeadIfDone() {
+	if p.closed && p.active == 0 {
+		close(p.dead)
+	}
+}
+
+// record 记录一个错误，返回处理是否应该继续
+func (p *ErrorPolicy) record(err error) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mode == FailFast && p.aborted {
+		// 已经中止，取消前就在路上的错误不再记录
+		return false
+	}
+	p.errs = append(p.errs, err)
+	if p.mode == FailFast {
+		p.aborted = true
+		p.cancel()
+		return false
+	}
+	return true
+}
+
+// Handle 按策略处理 Result 流中的错误，只把成功的值传给下游
+// 同一个策略可以用于多个 Handle 阶段，全部创建完后调用 Close
+func Handle[T any](ctx context.Context, p *ErrorPolicy, in <-chan Result[T]) <-chan T {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		panic("pipeline: ErrorPolicy 已经 Close, 不能再创建 Handle")
+	}
+	p.active++
+	p.mu.Unlock()
+
+	out := make(chan T)
+
+	go func() {
+		defer close(out)
+		defer p.handleDone()
+
+		for {
+			r, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+
+			if r.Err == nil {
+				if !send(ctx, out, r.Value) {
+					return
+				}
+				continue
+			}
+
+			if !p.record(r.Err) {
+				return
+			}
+			if p.mode == DeadLetter && !send(ctx, p.dead, r.Err) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// handleDone 在一个 Handle 结束时调用
+func (p *ErrorPolicy) handleDone() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active--
+	p.closeDeadIfDone()
+}
+
+// ErrorReport 是一条Pipeline结束后的错误汇总
+type ErrorReport struct {
+	Mode    ErrorMode
+	Errors  []error // 按发生顺序
+	Aborted bool    // FailFast 模式下是否因错误中止
+}
+
+// Failed 返回出错的元素数量
+func (r ErrorReport) Failed() int {
+	return len(r.Errors)
+}
+
+// Err 把所有错误合并为一个error，没有错误时返回nil
+func (r ErrorReport) Err() error {
+	return errors.Join(r.Errors...)
+}
+
+// ByStage 按阶段统计错误数量，不是 *StageError 的错误记在空字符串下
+func (r ErrorReport) ByStage() map[string]int {
+	counts := make(map[string]int)
+	for _, err := range r.Errors {
+		var se *StageError
+		if errors.As(err, &se) {
+			counts[se.Stage]++
+		} else {
+			counts[""]++
+		}
+	}
+	return counts
+}
+
+// Report 返回当前的错误汇总，应当在下游读完所有输出之后调用
+func (p *ErrorPolicy) Report() ErrorReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return ErrorReport{
+		Mode:    p.mode,
+		Errors:  append([]error(nil), p.errs...),
+		Aborted: p.aborted,
+	}
+}
@@ -0,0 +1,176 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// numbersWithError 对应 generateNumbersWithError：每3个数字有一个错误
+func numbersWithError(ctx context.Context, start, end int) <-chan Result[int] {
+	return Map(ctx, Generate(ctx, start, end), func(i int) Result[int] {
+		if i%3 == 0 {
+			return Fail[int](fmt.Errorf("处理数字 %d 时发生错误", i))
+		}
+		return Ok(i)
+	})
+}
+
+var errTooBig = errors.New("数字太大")
+
+// double 是一个会失败的处理阶段
+func double(x int) (int, error) {
+	if x > 8 {
+		return 0, errTooBig
+	}
+	return x * 2, nil
+}
+
+// TestSkipAndCollect 测试跳过错误元素并汇总错误
+func TestSkipAndCollect(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+
+	doubled := TryMap(ctx, "加倍", numbersWithError(ctx, 1, 10), double)
+	got := Collect(ctx, Handle(ctx, policy, doubled))
+
+	if want := []int{2, 4, 8, 10, 14, 16}; !reflect.DeepEqual(got, want) {
+		t.Errorf("输出 %v, 期望 %v", got, want)
+	}
+
+	report := policy.Report()
+	if report.Failed() != 4 {
+		t.Errorf("错误数 %d, 期望 4 (3,6,9 来自源头, 10 来自加倍阶段)", report.Failed())
+	}
+	if by := report.ByStage(); by["加倍"] != 1 || by[""] != 3 {
+		t.Errorf("按阶段统计 %v", by)
+	}
+	if !errors.Is(report.Err(), errTooBig) {
+		t.Errorf("汇总错误中应包含 errTooBig: %v", report.Err())
+	}
+	if report.Aborted {
+		t.Error("SkipAndCollect 不应中止")
+	}
+}
+
+// TestFailFast 测试第一个错误就取消整条Pipeline
+func TestFailFast(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), FailFast)
+	defer policy.Stop()
+
+	out := Handle(ctx, policy, TryMap(ctx, "加倍", numbersWithError(ctx, 1, 100), double))
+	policy.Close()
+	got := Collect(context.Background(), out)
+
+	if len(got) > 2 {
+		t.Errorf("FailFast 在第一个错误(3)之后还输出了 %v", got)
+	}
+	if ctx.Err() == nil {
+		t.Error("FailFast 应该取消context")
+	}
+
+	report := policy.Report()
+	if !report.Aborted || report.Failed() != 1 {
+		t.Errorf("报告 %+v, 期望中止且只有1个错误", report)
+	}
+	if _, ok := <-policy.DeadLetters(); ok {
+		t.Error("FailFast 模式不应产生死信")
+	}
+}
+
+// TestDeadLetter 测试出错元素发送到死信Channel
+func TestDeadLetter(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), DeadLetter)
+	defer policy.Stop()
+
+	out := Handle(ctx, policy, TryMap(ctx, "加倍", numbersWithError(ctx, 1, 10), double))
+	policy.Close()
+
+	var dead []error
+	done := make(chan struct{})
+	go func() {
+		for err := range policy.DeadLetters() {
+			dead = append(dead, err)
+		}
+		close(done)
+	}()
+
+	got := Collect(ctx, out)
+	<-done
+
+	if len(got) != 6 {
+		t.Errorf("输出 %v, 期望6个成功元素", got)
+	}
+	if len(dead) != 4 {
+		t.Fatalf("死信 %v, 期望4个", dead)
+	}
+
+	var se *StageError
+	if !errors.As(dead[3], &se) || se.Stage != "加倍" || se.Input != 10 {
+		t.Errorf("最后一个死信应该是加倍阶段处理10的错误, 实际 %v", dead[3])
+	}
+	if policy.Report().Failed() != 4 {
+		t.Errorf("报告错误数 %d, 期望 4", policy.Report().Failed())
+	}
+}
+
+// TestDeadLetterChainedHandles 测试同一个策略串联多个 Handle，
+// 第一个 Handle 在第二个创建之前就已经结束也不影响死信Channel
+func TestDeadLetterChainedHandles(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), DeadLetter)
+	defer policy.Stop()
+
+	first := Collect(ctx, Handle(ctx, policy, TryMap(ctx, "加倍", numbersWithError(ctx, 1, 10), double)))
+
+	second := Handle(ctx, policy, TryMap(ctx, "再加倍", Lift(ctx, FromSlice(ctx, first)), double))
+	policy.Close()
+
+	var dead []error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for err := range policy.DeadLetters() {
+			dead = append(dead, err)
+		}
+	}()
+
+	// 第一段的4个错误在缓冲区中，第二段的 10、14、16 超过8
+	got := Collect(ctx, second)
+	<-done
+	if want := []int{4, 8, 16}; !reflect.DeepEqual(got, want) {
+		t.Errorf("输出 %v, 期望 %v", got, want)
+	}
+	if len(dead) != 7 {
+		t.Errorf("死信 %d 个, 期望7个: %v", len(dead), dead)
+	}
+}
+
+// TestHandleAfterClose 测试 Close 之后不能再创建 Handle
+func TestHandleAfterClose(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+	policy.Close()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Close 之后调用 Handle 应当panic")
+		}
+	}()
+	Handle(ctx, policy, Lift(ctx, Generate(ctx, 1, 3)))
+}
+
+// TestNoErrors 测试没有错误时报告为空
+func TestNoErrors(t *testing.T) {
+	ctx, policy := NewErrorPolicy(context.Background(), SkipAndCollect)
+	defer policy.Stop()
+
+	got := Collect(ctx, Handle(ctx, policy, Lift(ctx, Generate(ctx, 1, 3))))
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("输出 %v", got)
+	}
+	if err := policy.Report().Err(); err != nil {
+		t.Errorf("期望没有错误, 实际 %v", err)
+	}
+}
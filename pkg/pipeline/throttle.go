@@ -0,0 +1,92 @@
+package pipeline
+
+import "context"
+
+// Waiter 是限流器需要实现的接口，ratelimit.TokenBucket 和 ratelimit.LeakyBucket 都满足它
+type Waiter interface {
+	Wait(ctx context.Context) error
+}
+
+// Throttle 每个元素都先经过限流器，再原样输出
+// 放在某个阶段之前即可限制该阶段的处理速率。
+// 限流器拒绝元素时（例如漏桶排队已满返回 ratelimit.ErrBucketFull）该元素被丢弃，
+// 需要知道哪些元素被拒绝时使用 TryThrottle
+func Throttle[T any](ctx context.Context, in <-chan T, limiter Waiter) <-chan T {
+	out := make(chan T)
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			v, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			start := sm.now()
+			err := limiter.Wait(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			sm.observe(start, err) // 被拒绝的元素计为错误
+			if err != nil {
+				continue
+			}
+			if !monitoredSend(ctx, sm, out, v) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// TryThrottle 是 Result 流上的 Throttle：上游的错误原样传递，
+// 限流器拒绝的元素变成 *StageError 向下游传递，可以交给 Handle 汇总或发送到死信Channel
+func TryThrottle[T any](ctx context.Context, stage string, in <-chan Result[T], limiter Waiter) <-chan Result[T] {
+	out := make(chan Result[T])
+	sm := stageOf(ctx)
+	watchOutput(sm, out)
+
+	go func() {
+		defer close(out)
+		sm.workerStarted()
+		defer sm.workerStopped()
+		for {
+			r, ok := monitoredRecv(ctx, sm, in)
+			if !ok {
+				return
+			}
+			if r.Err == nil {
+				start := sm.now()
+				err := limiter.Wait(ctx)
+				if ctx.Err() != nil {
+					return
+				}
+				sm.observe(start, err)
+				if err != nil {
+					r = Result[T]{Err: &StageError{Stage: stage, Input: r.Value, Err: err}}
+				}
+			}
+			if !monitoredSend(ctx, sm, out, r) {
+				return
+			}
+		}
+	}()
+
+	return out
+}
+
+// Limited 包装处理函数，每次调用前先经过限流器
+// 用于 Configured 的并行阶段或 workerpool 的任务函数，多个goroutine共享同一个限流器
+func Limited[T, U any](ctx context.Context, limiter Waiter, fn func(T) (U, error)) func(T) (U, error) {
+	return func(v T) (U, error) {
+		if err := limiter.Wait(ctx); err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(v)
+	}
+}
@@ -0,0 +1,128 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// TestThrottle 测试限流阶段按令牌桶速率放行
+func TestThrottle(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := ratelimit.NewTokenBucket(10, 2, clock) // 每100ms一个，突发2个
+
+	ctx := context.Background()
+	out := Throttle(ctx, Generate(ctx, 1, 5), limiter)
+
+	// 突发的2个立即到达
+	for i := 1; i <= 2; i++ {
+		if v := <-out; v != i {
+			t.Fatalf("收到 %d, 期望 %d", v, i)
+		}
+	}
+
+	// 之后每推进100ms放行一个
+	for i := 3; i <= 5; i++ {
+		select {
+		case v := <-out:
+			t.Fatalf("时间未推进就收到了 %d", v)
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		clock.Advance(100 * time.Millisecond)
+		if v := <-out; v != i {
+			t.Fatalf("收到 %d, 期望 %d", v, i)
+		}
+	}
+
+	if _, ok := <-out; ok {
+		t.Error("输入结束后输出应该关闭")
+	}
+}
+
+// TestThrottleCancel 测试等待令牌时取消不会泄漏goroutine
+func TestThrottleCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	clock := ratelimit.NewFakeClock(time.Now())
+	limiter := ratelimit.NewTokenBucket(1, 1, clock)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := Throttle(ctx, Generate(ctx, 1, 100), limiter)
+	<-out // 第一个使用突发令牌
+
+	cancel() // 第二个在等待令牌时被取消
+	for range out {
+	}
+	checkGoroutineLeak(t, baseline)
+}
+
+// rejectEvery 是每n次调用拒绝一次的限流器
+type rejectEvery struct {
+	n     int
+	calls int
+}
+
+func (r *rejectEvery) Wait(context.Context) error {
+	r.calls++
+	if r.calls%r.n == 0 {
+		return ratelimit.ErrBucketFull
+	}
+	return nil
+}
+
+// TestTryThrottleRejected 测试限流器拒绝的元素变成错误向下游传递，而不是被悄悄丢弃
+func TestTryThrottleRejected(t *testing.T) {
+	ctx := context.Background()
+	out := TryThrottle(ctx, "限流", Lift(ctx, Generate(ctx, 1, 4)), &rejectEvery{n: 2})
+
+	var ok []int
+	var rejected []any
+	for r := range out {
+		var se *StageError
+		switch {
+		case r.Err == nil:
+			ok = append(ok, r.Value)
+		case errors.As(r.Err, &se) && se.Stage == "限流" && errors.Is(r.Err, ratelimit.ErrBucketFull):
+			rejected = append(rejected, se.Input)
+		default:
+			t.Fatalf("意外的错误 %v", r.Err)
+		}
+	}
+	if !reflect.DeepEqual(ok, []int{1, 3}) || !reflect.DeepEqual(rejected, []any{2, 4}) {
+		t.Errorf("通过 %v 拒绝 %v, 期望 [1 3] 和 [2 4]", ok, rejected)
+	}
+}
+
+// TestConfiguredStageRate 测试配置中的rate限制阶段速率
+func TestConfiguredStageRate(t *testing.T) {
+	cfg := &Config{Stages: []StageConfig{
+		{Stage: "加倍", Parallelism: 4, Rate: 200, Burst: 1},
+	}}
+	p, err := Build(cfg, IntRegistry())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	got := Collect(ctx, p.Run(ctx, Generate(ctx, 1, 11)))
+	elapsed := time.Since(start)
+
+	if len(got) != 11 {
+		t.Fatalf("期望11个结果, 实际 %d", len(got))
+	}
+	// 第1个用突发令牌，之后10个每个间隔5ms，即使4个goroutine并行也至少50ms
+	if elapsed < 45*time.Millisecond {
+		t.Errorf("限流200/s处理11个元素只用了 %v", elapsed)
+	}
+
+	if err := Validate(&Config{Stages: []StageConfig{{Stage: "加倍", Rate: -1}}}, IntRegistry()); err == nil {
+		t.Error("负数rate应该校验失败")
+	}
+}
@@ -0,0 +1,389 @@
+package pipeline
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// Window 是一个左闭右开的时间区间 [Start, End)
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (w Window) String() string {
+	return fmt.Sprintf("[%s, %s)", w.Start.Format("15:04:05.000"), w.End.Format("15:04:05.000"))
+}
+
+// WindowResult 是一个key在一个窗口内的聚合结果
+type WindowResult[K comparable, A any] struct {
+	Key    K
+	Window Window
+	Value  A
+	Count  int // 窗口内的元素数
+}
+
+type windowKind int
+
+const (
+	tumbling windowKind = iota
+	sliding
+	session
+)
+
+// WindowSpec 描述窗口的划分方式，由 Tumbling、Sliding、Session 创建
+type WindowSpec struct {
+	kind  windowKind
+	size  time.Duration
+	slide time.Duration
+	gap   time.Duration
+}
+
+// Tumbling 固定大小、互不重叠的窗口，例如"每秒的总和"
+func Tumbling(size time.Duration) WindowSpec {
+	return WindowSpec{kind: tumbling, size: size, slide: size}
+}
+
+// Sliding 大小为size、每隔slide开始一个新窗口，一个元素可能属于多个窗口
+// 例如 Sliding(time.Minute, 10*time.Second) 每10秒给出最近一分钟的结果
+// slide大于size时是采样窗口，落在两个窗口之间的元素被丢弃，不交给 OnLate
+func Sliding(size, slide time.Duration) WindowSpec {
+	return WindowSpec{kind: sliding, size: size, slide: slide}
+}
+
+// Session 同一个key的相邻元素间隔不超过gap时属于同一个窗口，
+// 超过gap没有新元素则窗口结束，适合统计一次访问会话
+func Session(gap time.Duration) WindowSpec {
+	return WindowSpec{kind: session, gap: gap}
+}
+
+// WindowConfig 配置 Windowed 阶段
+//
+// 事件时间与水位线：设置 EventTime 后窗口按元素自带的时间划分，
+// 水位线 = 已见过的最大事件时间 - MaxDelay，表示"不会再有更早的元素了"。
+// 窗口结束时间不晚于水位线时输出结果；此后才到达、所属窗口都已输出的元素是迟到数据，
+// 交给 OnLate 处理后丢弃。事件时间模式下水位线只随新元素推进，输入结束时输出所有剩余窗口。
+//
+// 不设置 EventTime 时使用处理时间：元素的时间就是收到它的时间，窗口到点即输出。
+type WindowConfig[T any, K comparable, A any] struct {
+	Spec WindowSpec
+	// Key 把元素分组，每个key独立计算窗口，nil表示所有元素同一组
+	Key func(T) K
+	// Init 返回一个窗口的初始聚合值
+	Init func() A
+	// Add 把一个元素加入聚合值
+	Add func(A, T) A
+	// Merge 合并两个聚合值，会话窗口因乱序元素连接两个会话时需要，其他窗口可以为nil
+	Merge func(A, A) A
+
+	// EventTime 返回元素的事件时间，nil表示使用处理时间
+	EventTime func(T) time.Time
+	// MaxDelay 允许的最大乱序时间，只在事件时间模式下使用
+	MaxDelay time.Duration
+	// OnLate 处理迟到的元素，nil表示直接丢弃
+	OnLate func(T)
+	// Clock 处理时间模式下的时间来源，nil表示使用系统时间
+	Clock ratelimit.Clock
+}
+
+// winState 是一个尚未输出的窗口
+type winState[K comparable, A any] struct {
+	key    K
+	window Window
+	value  A
+	count  int
+	seq    uint64 // 创建顺序，结束时间相同的窗口按它排序，保证输出稳定
+	index  int    // 在pending堆中的位置
+}
+
+type winKey[K comparable] struct {
+	key   K
+	start int64
+}
+
+// windower 保存 Windowed 阶段的状态，只在一个goroutine中使用
+type windower[T any, K comparable, A any] struct {
+	cfg       WindowConfig[T, K, A]
+	fixed     map[winKey[K]]*winState[K, A] // 滚动和滑动窗口
+	sessions  map[K][]*winState[K, A]       // 会话窗口
+	pending   winHeap[K, A]                 // 所有未输出的窗口，按结束时间排列
+	seq       uint64
+	maxEvent  time.Time
+	watermark time.Time
+}
+
+// Windowed 按窗口对流做分组聚合，输出每个key每个窗口的结果
+// 会话窗口必须提供 Merge，窗口大小、滑动步长、会话间隔必须为正，否则panic
+func Windowed[T any, K comparable, A any](ctx context.Context, in <-chan T, cfg WindowConfig[T, K, A]) <-chan WindowResult[K, A] {
+	spec := cfg.Spec
+	switch {
+	case spec.kind == session && spec.gap <= 0:
+		panic("pipeline: 会话窗口的间隔必须为正")
+	case spec.kind == session && cfg.Merge == nil:
+		panic("pipeline: 会话窗口需要 Merge")
+	case spec.kind != session && (spec.size <= 0 || spec.slide <= 0):
+		panic("pipeline: 窗口大小和滑动步长必须为正")
+	}
+	if cfg.Key == nil {
+		cfg.Key = func(T) K {
+			var zero K
+			return zero
+		}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = ratelimit.RealClock{}
+	}
+
+	w := &windower[T, K, A]{
+		cfg:      cfg,
+		fixed:    make(map[winKey[K]]*winState[K, A]),
+		sessions: make(map[K][]*winState[K, A]),
+	}
+	out := make(chan WindowResult[K, A])
+
+	go func() {
+		defer close(out)
+
+		// 处理时间模式下，即使没有新元素，最早的窗口到点也要输出
+		// 只用一个计时器，最早的结束时间变化时才重置，避免每个元素都创建一个计时器
+		var (
+			timer    ratelimit.Timer
+			deadline time.Time
+		)
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			var fired <-chan time.Time
+			if cfg.EventTime == nil {
+				end, ok := w.earliestEnd()
+				switch {
+				case ok && !end.Equal(deadline):
+					if timer == nil {
+						timer = cfg.Clock.NewTimer(end.Sub(cfg.Clock.Now()))
+					} else {
+						timer.Reset(end.Sub(cfg.Clock.Now()))
+					}
+					deadline = end
+				case !ok && timer != nil && !deadline.IsZero():
+					timer.Stop()
+					deadline = time.Time{}
+				}
+				if ok {
+					fired = timer.C()
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-fired:
+				w.watermark = cfg.Clock.Now()
+				deadline = time.Time{}
+			case v, ok := <-in:
+				if !ok {
+					// 输入结束，不会再有元素，输出所有窗口
+					for _, r := range w.fire(time.Time{}, true) {
+						if !send(ctx, out, r) {
+							return
+						}
+					}
+					return
+				}
+				w.add(v)
+			}
+
+			for _, r := range w.fire(w.watermark, false) {
+				if !send(ctx, out, r) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// add 把一个元素放入它所属的窗口，并推进水位线
+func (w *windower[T, K, A]) add(v T) {
+	var t time.Time
+	if w.cfg.EventTime != nil {
+		t = w.cfg.EventTime(v)
+		if t.After(w.maxEvent) {
+			w.maxEvent = t
+			w.watermark = t.Add(-w.cfg.MaxDelay)
+		}
+	} else {
+		t = w.cfg.Clock.Now()
+		w.watermark = t
+	}
+
+	key := w.cfg.Key(v)
+	spec := w.cfg.Spec
+
+	if spec.kind == session {
+		w.addSession(key, t, v)
+		return
+	}
+
+	// 属于哪些窗口：最后一个窗口从 t 向下取整到slide开始，依次往前直到窗口不再包含t
+	// slide大于size时窗口之间有空隙，落在空隙中的元素不属于任何窗口，直接丢弃，不算迟到
+	covered, placed := false, false
+	for start := t.Truncate(spec.slide); start.Add(spec.size).After(t); start = start.Add(-spec.slide) {
+		covered = true
+		end := start.Add(spec.size)
+		if !end.After(w.watermark) {
+			continue // 这个窗口已经输出过了
+		}
+		k := winKey[K]{key: key, start: start.UnixNano()}
+		s, ok := w.fixed[k]
+		if !ok {
+			s = w.newState(key, Window{Start: start, End: end})
+			w.fixed[k] = s
+			heap.Push(&w.pending, s)
+		}
+		s.value = w.cfg.Add(s.value, v)
+		s.count++
+		placed = true
+	}
+	if covered && !placed {
+		w.late(v)
+	}
+}
+
+// addSession 把元素并入同一个key的会话，必要时合并被它连接起来的会话
+//
+// 元素自己的区间 [t, t+gap) 已经不晚于水位线时，如果它能并入一个还没输出的会话就不算迟到
+func (w *windower[T, K, A]) addSession(key K, t time.Time, v T) {
+	gap := w.cfg.Spec.gap
+	win := Window{Start: t, End: t.Add(gap)}
+
+	// 会话区间重叠或首尾相接，说明相邻元素的间隔不超过gap，需要合并
+	var overlapping, rest []*winState[K, A]
+	for _, s := range w.sessions[key] {
+		if s.window.Start.After(win.End) || win.Start.After(s.window.End) {
+			rest = append(rest, s)
+		} else {
+			overlapping = append(overlapping, s)
+		}
+	}
+	if len(overlapping) == 0 && !win.End.After(w.watermark) {
+		w.late(v)
+		return
+	}
+
+	merged := w.newState(key, win)
+	merged.value = w.cfg.Add(merged.value, v)
+	merged.count = 1
+	for _, s := range overlapping {
+		if s.window.Start.Before(merged.window.Start) {
+			merged.window.Start = s.window.Start
+		}
+		if s.window.End.After(merged.window.End) {
+			merged.window.End = s.window.End
+		}
+		merged.value = w.cfg.Merge(s.value, merged.value)
+		merged.count += s.count
+		if s.seq < merged.seq {
+			merged.seq = s.seq
+		}
+		heap.Remove(&w.pending, s.index)
+	}
+	w.sessions[key] = append(rest, merged)
+	heap.Push(&w.pending, merged)
+}
+
+func (w *windower[T, K, A]) newState(key K, win Window) *winState[K, A] {
+	w.seq++
+	return &winState[K, A]{key: key, window: win, value: w.cfg.Init(), seq: w.seq}
+}
+
+func (w *windower[T, K, A]) late(v T) {
+	if w.cfg.OnLate != nil {
+		w.cfg.OnLate(v)
+	}
+}
+
+// earliestEnd 返回尚未输出的窗口中最早的结束时间
+func (w *windower[T, K, A]) earliestEnd() (time.Time, bool) {
+	if len(w.pending) == 0 {
+		return time.Time{}, false
+	}
+	return w.pending[0].window.End, true
+}
+
+// fire 取出结束时间不晚于watermark的窗口（all为true时取出全部），按结束时间排序
+func (w *windower[T, K, A]) fire(watermark time.Time, all bool) []WindowResult[K, A] {
+	if watermark.IsZero() && !all {
+		return nil
+	}
+
+	var results []WindowResult[K, A]
+	for len(w.pending) > 0 && (all || !w.pending[0].window.End.After(watermark)) {
+		s := heap.Pop(&w.pending).(*winState[K, A])
+		if w.cfg.Spec.kind == session {
+			w.removeSession(s)
+		} else {
+			delete(w.fixed, winKey[K]{key: s.key, start: s.window.Start.UnixNano()})
+		}
+		results = append(results, WindowResult[K, A]{Key: s.key, Window: s.window, Value: s.value, Count: s.count})
+	}
+	return results
+}
+
+// removeSession 把已输出的会话从它的key下删除
+func (w *windower[T, K, A]) removeSession(s *winState[K, A]) {
+	list := w.sessions[s.key]
+	for i, x := range list {
+		if x == s {
+			list = append(list[:i], list[i+1:]...)
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(w.sessions, s.key)
+	} else {
+		w.sessions[s.key] = list
+	}
+}
+
+// winHeap 实现 heap.Interface，结束时间早的窗口在前，相同时按创建顺序
+type winHeap[K comparable, A any] []*winState[K, A]
+
+func (h winHeap[K, A]) Len() int { return len(h) }
+
+func (h winHeap[K, A]) Less(i, j int) bool {
+	a, b := h[i], h[j]
+	if !a.window.End.Equal(b.window.End) {
+		return a.window.End.Before(b.window.End)
+	}
+	return a.seq < b.seq
+}
+
+func (h winHeap[K, A]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *winHeap[K, A]) Push(x any) {
+	s := x.(*winState[K, A])
+	s.index = len(*h)
+	*h = append(*h, s)
+}
+
+func (h *winHeap[K, A]) Pop() any {
+	old := *h
+	n := len(old)
+	s := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return s
+}
@@ -0,0 +1,355 @@
+package pipeline
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// logEntry 模拟实时日志指标中的一条记录
+type logEntry struct {
+	level string
+	at    time.Time
+	bytes int
+}
+
+var t0 = time.Unix(1_700_000_000, 0)
+
+// at 返回t0之后ms毫秒的时间
+func at(ms int) time.Time {
+	return t0.Add(time.Duration(ms) * time.Millisecond)
+}
+
+// summary 把窗口结果转换成便于比较的形式：key@窗口起点毫秒=值/个数
+type summary struct {
+	Key   string
+	Start int
+	End   int
+	Value int
+	Count int
+}
+
+func summarize(results []WindowResult[string, int]) []summary {
+	out := make([]summary, len(results))
+	for i, r := range results {
+		out[i] = summary{
+			Key:   r.Key,
+			Start: int(r.Window.Start.Sub(t0) / time.Millisecond),
+			End:   int(r.Window.End.Sub(t0) / time.Millisecond),
+			Value: r.Value,
+			Count: r.Count,
+		}
+	}
+	return out
+}
+
+func sumBytes() WindowConfig[logEntry, string, int] {
+	return WindowConfig[logEntry, string, int]{
+		Key:       func(e logEntry) string { return e.level },
+		Init:      func() int { return 0 },
+		Add:       func(acc int, e logEntry) int { return acc + e.bytes },
+		Merge:     func(a, b int) int { return a + b },
+		EventTime: func(e logEntry) time.Time { return e.at },
+	}
+}
+
+// TestTumblingEventTime 测试按事件时间的每秒求和，乱序在允许范围内的元素仍被计入，迟到的元素被丢弃
+func TestTumblingEventTime(t *testing.T) {
+	entries := []logEntry{
+		{"info", at(100), 1},
+		{"error", at(200), 10},
+		{"info", at(900), 2},
+		{"info", at(1100), 4},
+		{"info", at(800), 8}, // 乱序，但水位线还是 1100-500=600，第一个窗口还没输出
+		{"error", at(1700), 20},
+		{"info", at(2600), 16},  // 水位线推进到2100，输出第一秒
+		{"info", at(300), 1000}, // 迟到：第一秒已经输出
+		{"info", at(2700), 32},
+	}
+
+	var late []logEntry
+	cfg := sumBytes()
+	cfg.Spec = Tumbling(time.Second)
+	cfg.MaxDelay = 500 * time.Millisecond
+	cfg.OnLate = func(e logEntry) { late = append(late, e) }
+
+	ctx := context.Background()
+	got := summarize(Collect(ctx, Windowed(ctx, FromSlice(ctx, entries), cfg)))
+	want := []summary{
+		{"info", 0, 1000, 11, 3},
+		{"error", 0, 1000, 10, 1},
+		{"info", 1000, 2000, 4, 1},
+		{"error", 1000, 2000, 20, 1},
+		{"info", 2000, 3000, 48, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("窗口结果\n%v\n期望\n%v", got, want)
+	}
+	if len(late) != 1 || late[0].bytes != 1000 {
+		t.Errorf("迟到数据 %v, 期望只有 bytes=1000 的一条", late)
+	}
+}
+
+// TestSlidingWindow 测试一个元素计入它所在的所有滑动窗口
+func TestSlidingWindow(t *testing.T) {
+	entries := []logEntry{
+		{"info", at(500), 1},
+		{"info", at(1500), 2},
+		{"info", at(2500), 4},
+	}
+	cfg := sumBytes()
+	cfg.Spec = Sliding(2*time.Second, time.Second)
+
+	ctx := context.Background()
+	got := summarize(Collect(ctx, Windowed(ctx, FromSlice(ctx, entries), cfg)))
+	want := []summary{
+		{"info", -1000, 1000, 1, 1},
+		{"info", 0, 2000, 3, 2},
+		{"info", 1000, 3000, 6, 2},
+		{"info", 2000, 4000, 4, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("窗口结果\n%v\n期望\n%v", got, want)
+	}
+}
+
+// TestSlidingWindowGap 测试滑动步长大于窗口大小时，落在窗口之间的元素被丢弃而不算迟到
+func TestSlidingWindowGap(t *testing.T) {
+	entries := []logEntry{
+		{"info", at(100), 1},
+		{"info", at(700), 2}, // 落在 [500, 1000) 的空隙中
+		{"info", at(1200), 4},
+	}
+	var late []logEntry
+	cfg := sumBytes()
+	cfg.Spec = Sliding(500*time.Millisecond, time.Second)
+	cfg.OnLate = func(e logEntry) { late = append(late, e) }
+
+	ctx := context.Background()
+	got := summarize(Collect(ctx, Windowed(ctx, FromSlice(ctx, entries), cfg)))
+	want := []summary{
+		{"info", 0, 500, 1, 1},
+		{"info", 1000, 1500, 4, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("窗口结果\n%v\n期望\n%v", got, want)
+	}
+	if len(late) != 0 {
+		t.Errorf("空隙中的元素不是迟到数据: %v", late)
+	}
+}
+
+// TestSessionWindowLateMerge 测试自身区间已经在水位线之前、但能并入未输出会话的元素不算迟到
+func TestSessionWindowLateMerge(t *testing.T) {
+	entries := []logEntry{
+		{"alice", at(1000), 1},
+		{"bob", at(2900), 100}, // 水位线推进到2900，alice的会话 [1000, 3000) 还没输出
+		{"alice", at(500), 2},  // 自身区间 [500, 2500) 在水位线之前，但与未输出的会话相连
+	}
+	var late []logEntry
+	cfg := sumBytes()
+	cfg.Spec = Session(2 * time.Second)
+	cfg.OnLate = func(e logEntry) { late = append(late, e) }
+
+	ctx := context.Background()
+	got := summarize(Collect(ctx, Windowed(ctx, FromSlice(ctx, entries), cfg)))
+	want := []summary{
+		{"alice", 500, 3000, 3, 2},
+		{"bob", 2900, 4900, 100, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("窗口结果\n%v\n期望\n%v", got, want)
+	}
+	if len(late) != 0 {
+		t.Errorf("能并入会话的元素不是迟到数据: %v", late)
+	}
+}
+
+// TestSessionWindow 测试会话窗口按间隔切分，乱序元素连接两个会话时合并
+func TestSessionWindow(t *testing.T) {
+	entries := []logEntry{
+		{"alice", at(0), 1},
+		{"bob", at(500), 100},
+		{"alice", at(1000), 2},
+		{"alice", at(4000), 4}, // 与上一个间隔3秒，超过2秒的间隔，开始新会话
+		{"alice", at(2500), 8}, // 乱序到达，把两个会话连起来
+		{"alice", at(9000), 16},
+	}
+	cfg := sumBytes()
+	cfg.Spec = Session(2 * time.Second)
+	cfg.MaxDelay = 3 * time.Second
+
+	ctx := context.Background()
+	got := summarize(Collect(ctx, Windowed(ctx, FromSlice(ctx, entries), cfg)))
+	want := []summary{
+		{"bob", 500, 2500, 100, 1},
+		{"alice", 0, 6000, 15, 4},
+		{"alice", 9000, 11000, 16, 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("窗口结果\n%v\n期望\n%v", got, want)
+	}
+}
+
+// TestWindowTopN 测试用聚合函数计算每分钟字节数最多的前2个级别
+func TestWindowTopN(t *testing.T) {
+	type counts map[string]int
+	entries := []logEntry{
+		{"info", at(0), 5}, {"warn", at(1000), 7}, {"error", at(2000), 3},
+		{"info", at(3000), 5}, {"debug", at(61000), 1},
+	}
+
+	ctx := context.Background()
+	out := Windowed(ctx, FromSlice(ctx, entries), WindowConfig[logEntry, struct{}, counts]{
+		Spec:      Tumbling(time.Minute),
+		Init:      func() counts { return counts{} },
+		Add:       func(c counts, e logEntry) counts { c[e.level] += e.bytes; return c },
+		EventTime: func(e logEntry) time.Time { return e.at },
+	})
+
+	var tops [][]string
+	for r := range out {
+		levels := make([]string, 0, len(r.Value))
+		for level := range r.Value {
+			levels = append(levels, level)
+		}
+		sort.Slice(levels, func(i, j int) bool { return r.Value[levels[i]] > r.Value[levels[j]] })
+		if len(levels) > 2 {
+			levels = levels[:2]
+		}
+		tops = append(tops, levels)
+	}
+
+	want := [][]string{{"info", "warn"}, {"debug"}}
+	if !reflect.DeepEqual(tops, want) {
+		t.Errorf("每分钟前2名 %v, 期望 %v", tops, want)
+	}
+}
+
+// TestWindowProcessingTime 测试处理时间模式下没有新元素时窗口也会到点输出
+func TestWindowProcessingTime(t *testing.T) {
+	clock := ratelimit.NewFakeClock(t0)
+	in := make(chan string)
+	// Key 在读取处理时间之后调用，测试用它确认元素已经按当前时间放入窗口
+	keyed := make(chan string, 10)
+
+	ctx := context.Background()
+	out := Windowed(ctx, in, WindowConfig[string, string, int]{
+		Spec: Tumbling(time.Second),
+		Key: func(s string) string {
+			keyed <- s
+			return s
+		},
+		Init:  func() int { return 0 },
+		Add:   func(n int, _ string) int { return n + 1 },
+		Clock: clock,
+	})
+
+	waitTimer := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for clock.Waiters() < n {
+			if time.Now().After(deadline) {
+				t.Fatal("窗口goroutine没有开始等待")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	in <- "a"
+	<-keyed
+	in <- "a"
+	<-keyed
+	waitTimer(1)
+	if n := clock.Waiters(); n != 1 {
+		t.Fatalf("同一个窗口只应有1个计时器在等待, 实际 %d", n)
+	}
+	clock.Advance(time.Second)
+
+	select {
+	case r := <-out:
+		if r.Key != "a" || r.Value != 2 || !r.Window.End.Equal(t0.Add(time.Second)) {
+			t.Errorf("窗口结果 %+v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("推进时钟后窗口没有输出")
+	}
+
+	in <- "b"
+	<-keyed
+	close(in)
+	if r, ok := <-out; !ok || r.Key != "b" || r.Value != 1 {
+		t.Errorf("输入结束时应输出剩余窗口, 实际 %+v", r)
+	}
+	if _, ok := <-out; ok {
+		t.Error("输出应当被关闭")
+	}
+}
+
+// TestWindowCancel 测试取消后窗口goroutine退出
+func TestWindowCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	src := Map(ctx, Generate(ctx, 0, int(^uint(0)>>1)), func(i int) logEntry {
+		return logEntry{level: "info", at: at(i), bytes: 1}
+	})
+	cfg := sumBytes()
+	cfg.Spec = Tumbling(time.Millisecond)
+	Windowed(ctx, src, cfg) // 输出无人读取
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	checkGoroutineLeak(t, baseline)
+}
+
+// manyKeys 生成keys个key、跨越seconds秒的日志，每个key每100ms一条
+func manyKeys(keys, seconds int) []logEntry {
+	var entries []logEntry
+	for ms := 0; ms < seconds*1000; ms += 100 {
+		for k := 0; k < keys; k++ {
+			entries = append(entries, logEntry{level: strconv.Itoa(k), at: at(ms), bytes: 1})
+		}
+	}
+	return entries
+}
+
+// TestWindowManyKeys 测试大量key时窗口按结束时间依次输出，每个窗口的计数正确
+func TestWindowManyKeys(t *testing.T) {
+	cfg := sumBytes()
+	cfg.Spec = Tumbling(time.Second)
+
+	ctx := context.Background()
+	results := Collect(ctx, Windowed(ctx, FromSlice(ctx, manyKeys(500, 3)), cfg))
+	if len(results) != 1500 {
+		t.Fatalf("期望1500个窗口结果, 实际 %d", len(results))
+	}
+	for i, r := range results {
+		if r.Count != 10 || r.Value != 10 {
+			t.Fatalf("%s %v: 计数 %d 值 %d, 期望都为10", r.Key, r.Window, r.Count, r.Value)
+		}
+		if i > 0 && r.Window.End.Before(results[i-1].Window.End) {
+			t.Fatalf("第%d个结果的结束时间早于前一个", i)
+		}
+	}
+}
+
+// BenchmarkWindowedManyKeys 大量key同时打开窗口时每个元素的开销
+func BenchmarkWindowedManyKeys(b *testing.B) {
+	entries := manyKeys(5000, 2)
+	cfg := sumBytes()
+	cfg.Spec = Tumbling(time.Second)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx := context.Background()
+		for range Windowed(ctx, FromSlice(ctx, entries), cfg) {
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package queue 提供 week1/day2 buffered_channels.go 练习中各种缓冲区的实现。
+//
+// Go的Channel创建后容量就固定了。Elastic 提供和Channel一样的 In()/Out() 两端，
+// 内部用环形缓冲区保存元素，生产者突发时扩容，消费完后缩回，
+// 容量始终在配置的最小值和最大值之间。
+//
+// Queue 是有界队列，占用达到高水位时通过回调或订阅Channel通知生产者暂停，
+// 回落到低水位时通知恢复，生产者不需要用 select+default 轮询缓冲区是否已满。
+// 队列满时除了阻塞，还可以选择丢弃最新、丢弃最旧、采样或溢出到磁盘。
+//
+// PriorityMux 实现多级缓冲：按严格优先级或加权公平从多个Channel读取，
+// 弥补 select 在多个case就绪时随机选择、无法保证优先级的问题。
+//
+// Registry 实现缓冲区监控练习：登记命名的Channel和队列，定期采样占用、
+// 收发速率和处于满或空状态的时间，占用越过阈值（例如笔记中的0.8）时告警。
+package queue
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Elastic 是容量随负载伸缩的队列
+//
+// 使用方式与Channel相同：生产者向 In() 发送，发送完毕后关闭 In()；
+// 消费者从 Out() 读取，缓冲区中的元素全部交付后 Out() 被关闭。
+// 缓冲区达到最大容量时 In() 不再接收，生产者会像写满的Channel一样阻塞。
+type Elastic[T any] struct {
+	in  chan T
+	out chan T
+	min int
+	max int
+
+	length   atomic.Int64
+	capacity atomic.Int64
+	grows    atomic.Int64
+	shrinks  atomic.Int64
+	sent     atomic.Int64
+	received atomic.Int64
+}
+
+// NewElastic 创建一个容量在 [minCap, maxCap] 之间伸缩的队列
+// minCap 小于1时按1处理，maxCap 小于minCap时按minCap处理；
+// ctx取消后 Out() 被关闭，缓冲的元素被丢弃
+func NewElastic[T any](ctx context.Context, minCap, maxCap int) *Elastic[T] {
+	if minCap < 1 {
+		minCap = 1
+	}
+	if maxCap < minCap {
+		maxCap = minCap
+	}
+
+	q := &Elastic[T]{
+		in:  make(chan T),
+		out: make(chan T),
+		min: minCap,
+		max: maxCap,
+	}
+	q.capacity.Store(int64(minCap))
+	go q.run(ctx)
+	return q
+}
+
+// In 返回输入端，发送完毕后由生产者关闭
+func (q *Elastic[T]) In() chan<- T { return q.in }
+
+// Out 返回输出端
+func (q *Elastic[T]) Out() <-chan T { return q.out }
+
+// Len 返回缓冲区中的元素数（不含正在交付给消费者的那一个）
+func (q *Elastic[T]) Len() int { return int(q.length.Load()) }
+
+// Cap 返回当前缓冲区容量
+func (q *Elastic[T]) Cap() int { return int(q.capacity.Load()) }
+
+// Totals 返回累计进入和交付的元素数，供 Registry 计算速率
+func (q *Elastic[T]) Totals() (sent, received int64) {
+	return q.sent.Load(), q.received.Load()
+}
+
+// Resizes 返回扩容和缩容的次数
+func (q *Elastic[T]) Resizes() (grows, shrinks int64) {
+	return q.grows.Load(), q.shrinks.Load()
+}
+
+// run 在两端之间搬运元素
+//
+// 用nil Channel禁用select的分支：缓冲区满时不再从in读取，
+// 缓冲区空时不向out发送，in关闭且缓冲区清空后关闭out。
+func (q *Elastic[T]) run(ctx context.Context) {
+	defer close(q.out)
+
+	buf := newRing[T](q.min)
+	in := q.in
+
+	for in != nil || buf.Len() > 0 {
+		var (
+			out  chan T
+			next T
+		)
+		if buf.Len() > 0 {
+			out = q.out
+			next = buf.peek()
+		}
+		recvFrom := in
+		if buf.full() {
+			recvFrom = nil // 已经是最大容量，让生产者阻塞
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-recvFrom:
+			if !ok {
+				in = nil
+				continue
+			}
+			buf.push(v)
+			q.sent.Add(1)
+			q.grow(buf)
+		case out <- next:
+			buf.pop()
+			q.received.Add(1)
+			q.shrink(buf)
+		}
+		q.length.Store(int64(buf.Len()))
+	}
+}
+
+// grow 缓冲区满时容量翻倍，不超过max
+func (q *Elastic[T]) grow(buf *ring[T]) {
+	if !buf.full() || buf.Cap() >= q.max {
+		return
+	}
+	buf.resize(min(buf.Cap()*2, q.max))
+	q.capacity.Store(int64(buf.Cap()))
+	q.grows.Add(1)
+}
+
+// shrink 元素数降到容量的1/4时容量减半，不低于min
+// 用1/4而不是1/2作为阈值，避免元素数在边界附近来回波动时反复扩缩
+func (q *Elastic[T]) shrink(buf *ring[T]) {
+	if buf.Cap() <= q.min || buf.Len() > buf.Cap()/4 {
+		return
+	}
+	buf.resize(max(buf.Cap()/2, q.min))
+	q.capacity.Store(int64(buf.Cap()))
+	q.shrinks.Add(1)
+}
@@ -0,0 +1,191 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// checkGoroutineLeak 等待goroutine数量回落到基线，超时则报告泄漏
+func checkGoroutineLeak(t *testing.T, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	buf := make([]byte, 1<<16)
+	n := runtime.Stack(buf, true)
+	t.Errorf("goroutine泄漏: 基线 %d, 当前 %d\n%s", baseline, runtime.NumGoroutine(), buf[:n])
+}
+
+// waitFor 轮询直到cond成立，超时则失败
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待超时: %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestElasticFIFO 测试元素按发送顺序交付，关闭输入后输出也被关闭
+func TestElasticFIFO(t *testing.T) {
+	q := NewElastic[int](context.Background(), 2, 16)
+
+	go func() {
+		for i := 0; i < 1000; i++ {
+			q.In() <- i
+		}
+		close(q.In())
+	}()
+
+	next := 0
+	for v := range q.Out() {
+		if v != next {
+			t.Fatalf("收到 %d, 期望 %d", v, next)
+		}
+		next++
+	}
+	if next != 1000 {
+		t.Errorf("收到 %d 个元素, 期望1000", next)
+	}
+}
+
+// TestElasticGrowAndShrink 测试突发时扩容到最大值，消费完后缩回最小值
+func TestElasticGrowAndShrink(t *testing.T) {
+	q := NewElastic[int](context.Background(), 4, 64)
+	if q.Cap() != 4 {
+		t.Fatalf("初始容量 %d, 期望4", q.Cap())
+	}
+
+	// 先只生产不消费，生产结束后再消费，扩缩的次数才是确定的
+	for i := 0; i < 64; i++ {
+		q.In() <- i
+	}
+	waitFor(t, "缓冲区填满", func() bool { return q.Len() == 64 })
+	if q.Cap() != 64 {
+		t.Errorf("突发后容量 %d, 期望64", q.Cap())
+	}
+
+	// 达到最大容量后生产者阻塞
+	select {
+	case q.In() <- 64:
+		t.Fatal("达到最大容量后生产者应当阻塞")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(q.In())
+
+	next := 0
+	for v := range q.Out() {
+		if v != next {
+			t.Fatalf("收到 %d, 期望 %d", v, next)
+		}
+		next++
+	}
+	if next != 64 {
+		t.Errorf("收到 %d 个元素, 期望64", next)
+	}
+
+	if q.Cap() != 4 {
+		t.Errorf("消费完后容量 %d, 期望缩回4", q.Cap())
+	}
+	grows, shrinks := q.Resizes()
+	if grows != 4 || shrinks != 4 {
+		t.Errorf("扩容 %d 次 缩容 %d 次, 期望各4次(4→8→16→32→64→32→16→8→4)", grows, shrinks)
+	}
+}
+
+// TestElasticCancel 测试取消后内部goroutine退出并关闭输出
+func TestElasticCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	q := NewElastic[int](ctx, 1, 8)
+	for i := 0; i < 5; i++ {
+		q.In() <- i
+	}
+	cancel()
+
+	for range q.Out() {
+		// 取消后可能还能读到已经在select中就绪的元素，但Out最终会被关闭
+	}
+	checkGoroutineLeak(t, baseline)
+}
+
+// benchmarkSizes 对应 performanceExperiment 中比较的缓冲区大小
+var benchmarkSizes = []int{0, 1, 10, 100, 1000}
+
+// BenchmarkChannel 基准测试：performanceExperiment 中固定大小的Channel
+func BenchmarkChannel(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("缓冲%d", size), func(b *testing.B) {
+			ch := make(chan int, size)
+			go func() {
+				for i := 0; i < b.N; i++ {
+					ch <- i
+				}
+				close(ch)
+			}()
+			for range ch {
+			}
+		})
+	}
+}
+
+// BenchmarkElastic 基准测试：相同场景下的 Elastic，最大容量与固定Channel相同
+// Elastic 的每个元素要经过两次Channel交接，单次开销高于固定Channel，
+// 它的价值在于空闲时只占用最小容量的内存
+func BenchmarkElastic(b *testing.B) {
+	for _, size := range benchmarkSizes[1:] {
+		b.Run(fmt.Sprintf("最大%d", size), func(b *testing.B) {
+			q := NewElastic[int](context.Background(), 1, size)
+			go func() {
+				for i := 0; i < b.N; i++ {
+					q.In() <- i
+				}
+				close(q.In())
+			}()
+			for range q.Out() {
+			}
+		})
+	}
+}
+
+// BenchmarkElasticBurst 基准测试：生产者突发、消费者稍后才开始读取
+func BenchmarkElasticBurst(b *testing.B) {
+	const burst = 1000
+
+	b.Run("Channel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ch := make(chan int, burst)
+			for j := 0; j < burst; j++ {
+				ch <- j
+			}
+			close(ch)
+			for range ch {
+			}
+		}
+	})
+
+	b.Run("Elastic", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			q := NewElastic[int](context.Background(), 8, burst)
+			for j := 0; j < burst; j++ {
+				q.In() <- j
+			}
+			close(q.In())
+			for range q.Out() {
+			}
+		}
+	})
+}
@@ -0,0 +1,296 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// Gauge 是可以观察占用情况的缓冲区，Queue 和 Elastic 都实现了它
+type Gauge interface {
+	Len() int
+	Cap() int
+}
+
+// Flow 是能报告累计收发数量的缓冲区，Registry 用它计算发送和接收速率
+// 普通Channel无法知道收发了多少次，所以只能观察占用
+type Flow interface {
+	Totals() (sent, received int64)
+}
+
+// chanGauge 用反射读取任意方向Channel的 len 和 cap
+type chanGauge struct {
+	ch reflect.Value
+}
+
+func (g chanGauge) Len() int { return g.ch.Len() }
+
+func (g chanGauge) Cap() int { return g.ch.Cap() }
+
+// Alert 是一次占用越过告警阈值的事件
+type Alert struct {
+	Name      string
+	Len       int
+	Cap       int
+	Occupancy float64
+	Firing    bool // true表示占用升到阈值以上，false表示回落到阈值以下
+	At        time.Time
+}
+
+// RegistryOption 配置 Registry 的可选参数
+type RegistryOption func(*Registry)
+
+// WithClock 设置时间来源，测试中可以使用 ratelimit.FakeClock
+func WithClock(c ratelimit.Clock) RegistryOption {
+	return func(r *Registry) {
+		r.clock = c
+	}
+}
+
+// WithAlert 设置告警阈值（占用比例）和回调，对应笔记中"len(ch)/cap(ch) > 0.8 时考虑扩容或背压"
+// 回调在 Sample 所在的goroutine中同步调用
+func WithAlert(threshold float64, fn func(Alert)) RegistryOption {
+	return func(r *Registry) {
+		r.threshold = threshold
+		r.onAlert = fn
+	}
+}
+
+// Registry 登记命名的Channel和队列，定期采样它们的占用、收发速率以及处于满或空状态的时间
+type Registry struct {
+	clock     ratelimit.Clock
+	threshold float64
+	onAlert   func(Alert)
+
+	mu      sync.Mutex
+	entries []*entry
+}
+
+// entry 是一个被监控的缓冲区及其累计统计
+type entry struct {
+	name  string
+	gauge Gauge
+
+	sampled   bool
+	lastAt    time.Time
+	lastLen   int
+	lastCap   int
+	lastSent  int64
+	lastRecv  int64
+	sendRate  float64
+	recvRate  float64
+	maxLen    int
+	fullTime  time.Duration
+	emptyTime time.Duration
+	observed  time.Duration
+	alerting  bool
+}
+
+// NewRegistry 创建一个空的监控登记表
+func NewRegistry(opts ...RegistryOption) *Registry {
+	r := &Registry{clock: ratelimit.RealClock{}}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Register 登记一个缓冲区，名字重复时返回错误
+func (r *Registry) Register(name string, g Gauge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.entries {
+		if e.name == name {
+			return fmt.Errorf("queue: 监控名 %q 已被登记", name)
+		}
+	}
+	r.entries = append(r.entries, &entry{name: name, gauge: g})
+	return nil
+}
+
+// RegisterChan 登记一个普通Channel，任意元素类型和方向都可以
+func (r *Registry) RegisterChan(name string, ch any) error {
+	v := reflect.ValueOf(ch)
+	if v.Kind() != reflect.Chan {
+		return fmt.Errorf("queue: %q 不是Channel而是 %T", name, ch)
+	}
+	return r.Register(name, chanGauge{ch: v})
+}
+
+// Unregister 取消登记，名字不存在时什么也不做
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.entries {
+		if e.name == name {
+			r.entries = append(r.entries[:i], r.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Sample 对所有缓冲区采样一次
+//
+// 两次采样之间的时间按上一次采样时的状态计入"满"或"空"，
+// 速率是两次采样之间收发数量的差除以间隔。
+func (r *Registry) Sample() {
+	now := r.clock.Now()
+
+	r.mu.Lock()
+	var alerts []Alert
+	for _, e := range r.entries {
+		if a, ok := r.sample(e, now); ok {
+			alerts = append(alerts, a)
+		}
+	}
+	r.mu.Unlock()
+
+	// 回调在释放锁之后调用，回调中可以读取快照
+	for _, a := range alerts {
+		r.onAlert(a)
+	}
+}
+
+// sample 更新一个缓冲区的统计，越过告警阈值时返回告警，调用方必须持有锁
+func (r *Registry) sample(e *entry, now time.Time) (Alert, bool) {
+	n, c := e.gauge.Len(), e.gauge.Cap()
+	var sent, recv int64
+	flow, hasFlow := e.gauge.(Flow)
+	if hasFlow {
+		sent, recv = flow.Totals()
+	}
+
+	if e.sampled {
+		dt := now.Sub(e.lastAt)
+		e.observed += dt
+		switch {
+		case e.lastLen == 0:
+			e.emptyTime += dt
+		case e.lastCap > 0 && e.lastLen >= e.lastCap:
+			e.fullTime += dt
+		}
+		if hasFlow && dt > 0 {
+			e.sendRate = float64(sent-e.lastSent) / dt.Seconds()
+			e.recvRate = float64(recv-e.lastRecv) / dt.Seconds()
+		}
+	}
+	e.sampled = true
+	e.lastAt, e.lastLen, e.lastCap = now, n, c
+	e.lastSent, e.lastRecv = sent, recv
+	e.maxLen = max(e.maxLen, n)
+
+	if r.onAlert == nil || c == 0 {
+		return Alert{}, false
+	}
+	occupancy := float64(n) / float64(c)
+	firing := occupancy > r.threshold
+	if firing == e.alerting {
+		return Alert{}, false
+	}
+	e.alerting = firing
+	return Alert{Name: e.name, Len: n, Cap: c, Occupancy: occupancy, Firing: firing, At: now}, true
+}
+
+// Run 每隔interval采样一次，直到ctx取消
+func (r *Registry) Run(ctx context.Context, interval time.Duration) {
+	for {
+		r.Sample()
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(interval):
+		}
+	}
+}
+
+// Stat 是一个缓冲区最近一次采样的结果和累计统计
+type Stat struct {
+	Name      string
+	Len       int
+	Cap       int
+	MaxLen    int     // 采样到的最大元素数
+	Occupancy float64 // Len/Cap，非缓冲Channel为0
+	HasRates  bool    // 是否实现了 Flow，普通Channel为false
+	SendRate  float64 // 最近一个采样间隔内每秒放入的元素数
+	RecvRate  float64 // 最近一个采样间隔内每秒取出的元素数
+	FullTime  time.Duration
+	EmptyTime time.Duration
+	Observed  time.Duration // 第一次到最近一次采样的时间
+	Alerting  bool
+}
+
+// Snapshot 返回所有缓冲区的统计，按登记顺序排列
+func (r *Registry) Snapshot() []Stat {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make([]Stat, len(r.entries))
+	for i, e := range r.entries {
+		_, hasFlow := e.gauge.(Flow)
+		stats[i] = Stat{
+			Name:      e.name,
+			Len:       e.lastLen,
+			Cap:       e.lastCap,
+			MaxLen:    e.maxLen,
+			HasRates:  hasFlow,
+			SendRate:  e.sendRate,
+			RecvRate:  e.recvRate,
+			FullTime:  e.fullTime,
+			EmptyTime: e.emptyTime,
+			Observed:  e.observed,
+			Alerting:  e.alerting,
+		}
+		if e.lastCap > 0 {
+			stats[i].Occupancy = float64(e.lastLen) / float64(e.lastCap)
+		}
+	}
+	return stats
+}
+
+// WriteTable 把快照以表格形式写入w
+func (r *Registry) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "名称\t占用\t最大\t发送/秒\t接收/秒\t满\t空\t")
+	for _, s := range r.Snapshot() {
+		sendRate, recvRate := "-", "-"
+		if s.HasRates {
+			sendRate = fmt.Sprintf("%.1f", s.SendRate)
+			recvRate = fmt.Sprintf("%.1f", s.RecvRate)
+		}
+		mark := ""
+		if s.Alerting {
+			mark = "⚠️ 告警"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			s.Name, bar(s.Len, s.Cap), s.MaxLen, sendRate, recvRate,
+			share(s.FullTime, s.Observed), share(s.EmptyTime, s.Observed), mark)
+	}
+	return tw.Flush()
+}
+
+// bar 用字符条显示占用，例如 [########..] 8/10
+func bar(n, capacity int) string {
+	if capacity == 0 {
+		return fmt.Sprintf("非缓冲 %d", n)
+	}
+	const width = 10
+	filled := min(n*width/capacity, width)
+	return fmt.Sprintf("[%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat(".", width-filled), n, capacity)
+}
+
+// share 显示一段时间及其占观察时间的比例
+func share(d, total time.Duration) string {
+	if total <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%v (%.0f%%)", d.Round(time.Millisecond), float64(d)/float64(total)*100)
+}
@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/pkg/ratelimit"
+)
+
+// TestRegistryFullEmptyTime 测试满和空的时间按上一次采样时的状态累计
+func TestRegistryFullEmptyTime(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	r := NewRegistry(WithClock(clock))
+	ch := make(chan int, 2)
+	if err := r.RegisterChan("ch", ch); err != nil {
+		t.Fatal(err)
+	}
+
+	r.Sample() // 空
+	clock.Advance(3 * time.Second)
+	ch <- 1
+	ch <- 2
+	r.Sample() // 满
+	clock.Advance(time.Second)
+	<-ch
+	r.Sample() // 半满
+	clock.Advance(2 * time.Second)
+	r.Sample()
+
+	s := r.Snapshot()[0]
+	if s.EmptyTime != 3*time.Second || s.FullTime != time.Second || s.Observed != 6*time.Second {
+		t.Errorf("空 %v 满 %v 观察 %v，期望 3s 1s 6s", s.EmptyTime, s.FullTime, s.Observed)
+	}
+	if s.Len != 1 || s.Cap != 2 || s.MaxLen != 2 || s.Occupancy != 0.5 {
+		t.Errorf("快照 %+v", s)
+	}
+	if s.HasRates {
+		t.Error("普通Channel不应有速率")
+	}
+}
+
+// TestRegistryRates 测试实现了 Flow 的队列按采样间隔计算收发速率
+func TestRegistryRates(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	r := NewRegistry(WithClock(clock))
+	q := New[int](100)
+	if err := r.Register("q", q); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	r.Sample()
+	for i := 0; i < 20; i++ {
+		q.Put(ctx, i)
+	}
+	for i := 0; i < 5; i++ {
+		q.Get(ctx)
+	}
+	clock.Advance(2 * time.Second)
+	r.Sample()
+
+	s := r.Snapshot()[0]
+	if !s.HasRates || s.SendRate != 10 || s.RecvRate != 2.5 {
+		t.Errorf("速率 %v/%v，期望 10/2.5", s.SendRate, s.RecvRate)
+	}
+}
+
+// TestRegistryAlert 测试占用越过阈值和回落时各告警一次
+func TestRegistryAlert(t *testing.T) {
+	var alerts []Alert
+	r := NewRegistry(WithAlert(0.8, func(a Alert) { alerts = append(alerts, a) }))
+	ch := make(chan int, 10)
+	r.RegisterChan("ch", ch)
+
+	fill := func(n int) {
+		for len(ch) < n {
+			ch <- 0
+		}
+		for len(ch) > n {
+			<-ch
+		}
+		r.Sample()
+	}
+	fill(5)
+	fill(9)
+	fill(10) // 仍在阈值以上，不重复告警
+	fill(8)  // 0.8 不超过阈值，解除
+	fill(9)
+
+	if len(alerts) != 3 {
+		t.Fatalf("告警 %d 次，期望3次: %+v", len(alerts), alerts)
+	}
+	if !alerts[0].Firing || alerts[0].Len != 9 || alerts[1].Firing || !alerts[2].Firing {
+		t.Errorf("告警序列 %+v", alerts)
+	}
+	if !r.Snapshot()[0].Alerting {
+		t.Error("快照应处于告警状态")
+	}
+}
+
+// TestRegistryRegister 测试重复登记、非Channel和取消登记
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	var recvOnly <-chan int = make(chan int, 1)
+	if err := r.RegisterChan("a", recvOnly); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RegisterChan("a", make(chan int)); err == nil {
+		t.Error("重复名字应报错")
+	}
+	if err := r.RegisterChan("b", 42); err == nil {
+		t.Error("非Channel应报错")
+	}
+	r.Register("c", NewElastic[int](context.Background(), 1, 4))
+	r.Unregister("a")
+
+	snaps := r.Snapshot()
+	if len(snaps) != 1 || snaps[0].Name != "c" {
+		t.Errorf("快照 %+v", snaps)
+	}
+}
+
+// TestRegistryWriteTable 测试表格包含占用条、速率和告警标记
+func TestRegistryWriteTable(t *testing.T) {
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	r := NewRegistry(WithClock(clock), WithAlert(0.8, func(Alert) {}))
+	ch := make(chan int, 10)
+	for i := 0; i < 9; i++ {
+		ch <- i
+	}
+	r.RegisterChan("jobs", ch)
+	r.Register("results", New[int](4))
+	r.RegisterChan("sync", make(chan int))
+	r.Sample()
+	clock.Advance(time.Second)
+	r.Sample()
+
+	var b strings.Builder
+	if err := r.WriteTable(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{"[#########.] 9/10", "告警", "0.0", "非缓冲"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("表格缺少 %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestRegistryRun 测试 Run 定期采样并在ctx取消后返回
+func TestRegistryRun(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	clock := ratelimit.NewFakeClock(time.Unix(0, 0))
+	r := NewRegistry(WithClock(clock))
+	r.RegisterChan("ch", make(chan int, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		r.Run(ctx, time.Second)
+		close(done)
+	}()
+	for i := 0; i < 3; i++ {
+		waitFor(t, "Run 等待下一次采样", func() bool { return clock.Waiters() == 1 })
+		clock.Advance(time.Second)
+	}
+	waitFor(t, "第4次采样", func() bool { return r.Snapshot()[0].Observed == 3*time.Second })
+	cancel()
+	<-done
+	checkGoroutineLeak(t, baseline)
+}
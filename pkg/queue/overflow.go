@@ -0,0 +1,174 @@
+package queue
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OverflowPolicy 决定队列满时 Put 怎么做
+type OverflowPolicy int
+
+const (
+	// Block 等待消费者取走元素，与写满的Channel相同（默认）
+	Block OverflowPolicy = iota
+	// DropNewest 丢弃正在放入的元素，Put 立即返回
+	DropNewest
+	// DropOldest 丢弃队首最旧的元素，为新元素腾出位置
+	DropOldest
+	// Sample 队列满时每N个新元素保留1个（替换最旧的元素），其余丢弃，见 WithSampling
+	Sample
+	// Spill 把放不下的元素按顺序写入本地磁盘段，消费者取走内存中的元素后再读回来，见 WithSpill
+	Spill
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropNewest:
+		return "drop-newest"
+	case DropOldest:
+		return "drop-oldest"
+	case Sample:
+		return "sample"
+	case Spill:
+		return "spill"
+	default:
+		return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+	}
+}
+
+// WithOverflow 设置队列满时的处理方式
+// Sample 默认每2个保留1个，Spill 默认写入 os.TempDir()；
+// 需要其他参数时使用 WithSampling 或 WithSpill
+func WithOverflow(p OverflowPolicy) Option {
+	return func(c *config) {
+		c.overflow = p
+		switch {
+		case p == Sample && c.sampleEvery < 2:
+			c.sampleEvery = 2
+		case p == Spill && c.spillDir == "":
+			c.spillDir = os.TempDir()
+		}
+	}
+}
+
+// WithSampling 使用 Sample 策略，队列满时每n个新元素保留1个，n小于2时按2处理
+func WithSampling(n int) Option {
+	return func(c *config) {
+		if n < 2 {
+			n = 2
+		}
+		c.overflow = Sample
+		c.sampleEvery = n
+	}
+}
+
+// WithSpill 使用 Spill 策略，溢出的元素以JSON Lines格式写入dir下的临时文件
+// 元素类型必须能被encoding/json编解码；磁盘上的元素全部取回后文件会被删除。
+// 读取溢出文件失败时，磁盘上剩余的元素被丢弃并计入 Dropped，Get 返回该错误
+func WithSpill(dir string) Option {
+	return func(c *config) {
+		c.overflow = Spill
+		c.spillDir = dir
+	}
+}
+
+// Counters 是队列的溢出统计
+type Counters struct {
+	Accepted int64 // 成功放入的元素数（包括写入磁盘的）
+	Taken    int64 // 被 Get 取走的元素数
+	Dropped  int64 // 因溢出被丢弃的元素数（包括溢出文件损坏时丢失的）
+	Spilled  int64 // 累计写入磁盘的元素数
+	OnDisk   int   // 当前还在磁盘上的元素数
+}
+
+// spillFile 是一个先进先出的磁盘段：从尾部追加，从头部读取
+type spillFile[T any] struct {
+	path    string
+	w       *os.File
+	bw      *bufio.Writer
+	enc     *json.Encoder
+	r       *os.File
+	dec     *json.Decoder
+	pending int
+}
+
+func createSpill[T any](dir string) (*spillFile[T], error) {
+	w, err := os.CreateTemp(dir, "queue-spill-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		w.Close()
+		os.Remove(w.Name())
+		return nil, err
+	}
+	bw := bufio.NewWriter(w)
+	return &spillFile[T]{
+		path: w.Name(),
+		w:    w,
+		bw:   bw,
+		enc:  json.NewEncoder(bw),
+		r:    r,
+		dec:  json.NewDecoder(bufio.NewReader(r)),
+	}, nil
+}
+
+// write 把v追加到段尾
+func (s *spillFile[T]) write(v T) error {
+	if err := s.enc.Encode(v); err != nil {
+		return fmt.Errorf("queue: 写入溢出文件失败: %w", err)
+	}
+	s.pending++
+	return nil
+}
+
+// read 从段头读出一个元素，调用方负责保证 pending > 0
+func (s *spillFile[T]) read() (T, error) {
+	var v T
+	// 读之前把写缓冲刷到文件里，否则读端看不到最近写入的元素
+	if err := s.flush(); err != nil {
+		return v, err
+	}
+	if err := s.dec.Decode(&v); err != nil {
+		return v, fmt.Errorf("queue: 读取溢出文件失败: %w", err)
+	}
+	s.pending--
+	return v, nil
+}
+
+func (s *spillFile[T]) flush() error {
+	if s.w == nil {
+		return nil
+	}
+	if err := s.bw.Flush(); err != nil {
+		return fmt.Errorf("queue: 写入溢出文件失败: %w", err)
+	}
+	return nil
+}
+
+// closeWriter 刷新并关闭写端，之后只能读取，队列关闭后不会再有写入
+func (s *spillFile[T]) closeWriter() error {
+	if s.w == nil {
+		return nil
+	}
+	err := s.flush()
+	if cerr := s.w.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("queue: 写入溢出文件失败: %w", cerr)
+	}
+	s.w = nil
+	return err
+}
+
+// remove 关闭并删除磁盘段
+func (s *spillFile[T]) remove() {
+	if s.w != nil {
+		s.w.Close()
+	}
+	s.r.Close()
+	os.Remove(s.path)
+}
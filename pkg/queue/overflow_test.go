@@ -0,0 +1,213 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// drain 取出队列中当前所有元素
+func drain[T any](t *testing.T, q *Queue[T]) []T {
+	t.Helper()
+	q.Close()
+	var got []T
+	for {
+		v, err := q.Get(context.Background())
+		if err == ErrClosed {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("Get 返回错误: %v", err)
+		}
+		got = append(got, v)
+	}
+}
+
+// putAll 依次放入 [from, to]，任何一次 Put 返回错误都会让测试失败
+func putAll(t *testing.T, q *Queue[int], from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		if err := q.Put(context.Background(), i); err != nil {
+			t.Fatalf("Put(%d) 返回错误: %v", i, err)
+		}
+	}
+}
+
+// TestOverflowDrop 测试丢弃最新和丢弃最旧，队列满时 Put 都不阻塞
+func TestOverflowDrop(t *testing.T) {
+	tests := []struct {
+		policy OverflowPolicy
+		want   []int
+		counts Counters
+	}{
+		{DropNewest, []int{1, 2, 3}, Counters{Accepted: 3, Dropped: 7}},
+		{DropOldest, []int{8, 9, 10}, Counters{Accepted: 10, Dropped: 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			q := New[int](3, WithOverflow(tt.policy))
+			putAll(t, q, 1, 10)
+
+			if c := q.Counters(); c != tt.counts {
+				t.Errorf("统计 %+v, 期望 %+v", c, tt.counts)
+			}
+			if got := drain(t, q); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("队列内容 %v, 期望 %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOverflowSample 测试队列满时每3个新元素保留1个
+func TestOverflowSample(t *testing.T) {
+	q := New[int](2, WithSampling(3))
+	putAll(t, q, 1, 8)
+
+	// 1、2直接放入；之后的3..8中第3个(5)和第6个(8)替换最旧的元素
+	if got := drain(t, q); !reflect.DeepEqual(got, []int{5, 8}) {
+		t.Errorf("队列内容 %v, 期望 [5 8]", got)
+	}
+	if c := q.Counters(); c.Accepted != 4 || c.Dropped != 6 {
+		t.Errorf("统计 %+v, 期望放入4个丢弃6个", c)
+	}
+}
+
+// TestOverflowSampleDefault 测试只用 WithOverflow(Sample) 时默认每2个保留1个
+func TestOverflowSampleDefault(t *testing.T) {
+	q := New[int](1, WithOverflow(Sample))
+	putAll(t, q, 1, 5)
+
+	// 1直接放入；之后的2..5中第2个(3)和第4个(5)替换最旧的元素
+	if got := drain(t, q); !reflect.DeepEqual(got, []int{5}) {
+		t.Errorf("队列内容 %v, 期望 [5]", got)
+	}
+	if c := q.Counters(); c.Accepted != 3 || c.Dropped != 4 {
+		t.Errorf("统计 %+v, 期望放入3个丢弃4个", c)
+	}
+}
+
+// TestOverflowSpillDefaultDir 测试只用 WithOverflow(Spill) 时溢出文件写入 os.TempDir()
+func TestOverflowSpillDefaultDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	q := New[int](1, WithOverflow(Spill))
+	putAll(t, q, 1, 5)
+
+	if c := q.Counters(); c.Spilled != 4 || c.Dropped != 0 {
+		t.Errorf("统计 %+v, 期望写入磁盘4个", c)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("期望 os.TempDir() 下有1个溢出文件, 实际 %d", len(entries))
+	}
+	if got := drain(t, q); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("队列内容 %v, 期望 [1 2 3 4 5]", got)
+	}
+}
+
+// TestOverflowSpill 测试溢出到磁盘的元素按顺序取回，取完后删除溢出文件
+func TestOverflowSpill(t *testing.T) {
+	type logLine struct {
+		Seq int    `json:"seq"`
+		Msg string `json:"msg"`
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	q := New[logLine](4, WithSpill(dir))
+
+	for i := 0; i < 20; i++ {
+		if err := q.Put(ctx, logLine{Seq: i, Msg: "请求"}); err != nil {
+			t.Fatalf("Put 返回错误: %v", err)
+		}
+	}
+	if c := q.Counters(); c.Accepted != 20 || c.Spilled != 16 || c.OnDisk != 16 || c.Dropped != 0 {
+		t.Errorf("统计 %+v, 期望写入磁盘16个", c)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("期望1个溢出文件, 实际 %d", len(entries))
+	}
+
+	// 边取边放：磁盘上还有元素时新元素也应排在后面
+	next := 0
+	for i := 0; i < 10; i++ {
+		v, err := q.Get(ctx)
+		if err != nil || v.Seq != next {
+			t.Fatalf("Get 返回 %+v, %v, 期望序号 %d", v, err, next)
+		}
+		next++
+	}
+	q.Put(ctx, logLine{Seq: 20})
+	q.Put(ctx, logLine{Seq: 21})
+
+	for _, v := range drain(t, q) {
+		if v.Seq != next {
+			t.Fatalf("收到序号 %d, 期望 %d", v.Seq, next)
+		}
+		next++
+	}
+	if next != 22 {
+		t.Errorf("共收到 %d 个元素, 期望22", next)
+	}
+	if c := q.Counters(); c.OnDisk != 0 {
+		t.Errorf("取完后磁盘上还有 %d 个元素", c.OnDisk)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("取完后应删除溢出文件, 还剩 %d 个", len(entries))
+	}
+}
+
+// undecodable 可以写入溢出文件，但读回时解码失败，用来模拟损坏的溢出文件
+type undecodable int
+
+func (*undecodable) UnmarshalJSON([]byte) error {
+	return errors.New("损坏的记录")
+}
+
+// TestOverflowSpillCorrupt 测试溢出文件读取失败时剩余元素计入丢弃，错误只返回一次，之后不再读取文件
+func TestOverflowSpillCorrupt(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	q := New[undecodable](1, WithSpill(dir))
+	for i := 0; i < 3; i++ {
+		if err := q.Put(ctx, undecodable(i)); err != nil {
+			t.Fatalf("Put 返回错误: %v", err)
+		}
+	}
+
+	// 内存中的元素正常取出，之后补充内存时读取失败
+	if v, err := q.Get(ctx); err != nil || v != 0 {
+		t.Fatalf("Get 返回 %v, %v, 期望 0", v, err)
+	}
+	if _, err := q.Get(ctx); err == nil {
+		t.Fatal("溢出文件损坏时期望 Get 返回错误")
+	}
+	if c := q.Counters(); c.Dropped != 2 || c.OnDisk != 0 {
+		t.Errorf("统计 %+v, 期望磁盘上的2个元素计入丢弃", c)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("损坏的溢出文件应被删除, 还剩 %d 个", len(entries))
+	}
+
+	// 队列继续可用
+	q.Put(ctx, 7)
+	if got := drain(t, q); !reflect.DeepEqual(got, []undecodable{7}) {
+		t.Errorf("取出 %v, 期望 [7]", got)
+	}
+}
+
+// TestOverflowSpillUnwritable 测试溢出目录不可用时 Put 返回错误
+func TestOverflowSpillUnwritable(t *testing.T) {
+	q := New[int](1, WithSpill("/nonexistent/queue-spill"))
+	putAll(t, q, 1, 1)
+
+	if err := q.Put(context.Background(), 2); err == nil {
+		t.Error("溢出目录不存在时期望 Put 返回错误")
+	}
+	if c := q.Counters(); c.Accepted != 1 || c.Spilled != 0 {
+		t.Errorf("统计 %+v", c)
+	}
+}
@@ -0,0 +1,244 @@
+package queue
+
+import (
+	"context"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// PriorityMux 从多个输入Channel读取，按优先级合并到一个输出
+//
+// 普通的 select 在多个case同时就绪时随机选择，高优先级的元素可能排在低优先级后面。
+// PriorityMux 为每个输入预读一个元素，等所有输入都有预读的元素或者已经空闲后再决定交付哪一个：
+//   - 严格优先级：总是交付下标最小（优先级最高）的输入的元素，高优先级持续有数据时低优先级会饿死
+//   - 加权公平：按权重比例交付（平滑加权轮询），高优先级占大头，低优先级也能得到保底份额
+type PriorityMux[T any] struct {
+	out       chan T
+	inputs    []<-chan T
+	weights   []int // nil表示严格优先级
+	delivered []atomic.Int64
+}
+
+// StrictMux 创建严格优先级的多路复用器，inputs[0]的优先级最高
+func StrictMux[T any](ctx context.Context, inputs ...<-chan T) *PriorityMux[T] {
+	return newPriorityMux(ctx, inputs, nil)
+}
+
+// WeightedMux 创建加权公平的多路复用器，weights[i]是inputs[i]的权重，小于1的权重按1处理
+// 例如权重 [3, 1] 在两个输入都有数据时按 3:1 交付
+func WeightedMux[T any](ctx context.Context, weights []int, inputs ...<-chan T) *PriorityMux[T] {
+	w := make([]int, len(inputs))
+	for i := range w {
+		w[i] = 1
+		if i < len(weights) && weights[i] > 1 {
+			w[i] = weights[i]
+		}
+	}
+	return newPriorityMux(ctx, inputs, w)
+}
+
+func newPriorityMux[T any](ctx context.Context, inputs []<-chan T, weights []int) *PriorityMux[T] {
+	m := &PriorityMux[T]{
+		out:       make(chan T),
+		inputs:    inputs,
+		weights:   weights,
+		delivered: make([]atomic.Int64, len(inputs)),
+	}
+	go m.run(ctx)
+	return m
+}
+
+// Out 返回输出Channel，所有输入关闭且预读的元素交付完后被关闭，ctx取消时也会被关闭
+func (m *PriorityMux[T]) Out() <-chan T { return m.out }
+
+// Delivered 返回每个输入已经交付的元素数
+func (m *PriorityMux[T]) Delivered() []int64 {
+	counts := make([]int64, len(m.delivered))
+	for i := range m.delivered {
+		counts[i] = m.delivered[i].Load()
+	}
+	return counts
+}
+
+// idleAfter 是判定输入空闲的等待时间
+//
+// 刚交付过元素的输入在预读时可能只是生产者还没来得及补充，并不是真的没有数据。
+// 如果这时立即交付其他输入的元素，高优先级或高权重的输入就会平白丢掉一轮。
+// 所以这样的输入在最多 idleAfter 内没有新元素时才被视为空闲，空闲之前不做交付决定。
+const idleAfter = time.Millisecond
+
+// slot 是一个输入预读的元素
+type slot[T any] struct {
+	value  T
+	full   bool
+	closed bool
+	idle   bool // 槽为空且输入已经空闲了 idleAfter，做决定时不再等它
+}
+
+// pending 报告槽是否在等待输入补充：输入未关闭、槽为空且还没有被判定为空闲
+func (s *slot[T]) pending() bool {
+	return !s.full && !s.closed && !s.idle
+}
+
+func (m *PriorityMux[T]) run(ctx context.Context) {
+	defer close(m.out)
+
+	n := len(m.inputs)
+	slots := make([]slot[T], n)
+	current := make([]int, n) // 平滑加权轮询的当前值
+
+	timer := time.NewTimer(idleAfter)
+	defer timer.Stop()
+	armed := true
+
+	// select的case：0是ctx.Done，1是发送到out，2是空闲计时器，之后是每个输入的接收
+	// 输入数量在运行时才知道，只能用反射构造select
+	const (
+		caseDone = iota
+		caseSend
+		caseIdle
+		caseInput
+	)
+	cases := make([]reflect.SelectCase, caseInput+n)
+	cases[caseDone] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())}
+	cases[caseSend] = reflect.SelectCase{Dir: reflect.SelectSend}
+	cases[caseIdle] = reflect.SelectCase{Dir: reflect.SelectRecv}
+	for i := range m.inputs {
+		cases[caseInput+i] = reflect.SelectCase{Dir: reflect.SelectRecv}
+	}
+
+	for {
+		// 先不阻塞地把已经就绪的元素读进空槽，保证选择时看到的是所有已就绪的输入
+		waiting := false
+		for i, in := range m.inputs {
+			if !slots[i].full && !slots[i].closed {
+				select {
+				case v, ok := <-in:
+					if ok {
+						slots[i] = slot[T]{value: v, full: true}
+					} else {
+						slots[i].closed = true
+					}
+				default:
+				}
+			}
+			waiting = waiting || slots[i].pending()
+		}
+
+		// 还有输入可能马上补充时只等待输入或计时器，不提供发送，
+		// 否则所有未关闭的输入要么有预读的元素要么已经空闲，可以做决定了
+		chosen := -1
+		cases[caseSend].Chan = reflect.Value{}
+		cases[caseIdle].Chan = reflect.Value{}
+		if waiting {
+			if !armed {
+				timer.Reset(idleAfter)
+				armed = true
+			}
+			cases[caseIdle].Chan = reflect.ValueOf(timer.C)
+		} else {
+			if armed {
+				stopTimer(timer)
+				armed = false
+			}
+			chosen = m.choose(slots, current)
+			if chosen < 0 && allClosed(slots) {
+				return
+			}
+			if chosen >= 0 {
+				cases[caseSend].Chan = reflect.ValueOf(m.out)
+				cases[caseSend].Send = reflect.ValueOf(&slots[chosen].value).Elem()
+			}
+		}
+		for i, in := range m.inputs {
+			cases[caseInput+i].Chan = reflect.Value{}
+			if !slots[i].full && !slots[i].closed {
+				cases[caseInput+i].Chan = reflect.ValueOf(in)
+			}
+		}
+
+		// 已空闲的输入和交付同时就绪时两者的先后没有定义，
+		// 决定之前已经在输入Channel中的元素总是先被看到
+		i, v, ok := reflect.Select(cases)
+		switch {
+		case i == caseDone:
+			return
+		case i == caseSend:
+			m.commit(slots, current, chosen)
+			slots[chosen] = slot[T]{}
+			m.delivered[chosen].Add(1)
+		case i == caseIdle:
+			armed = false
+			for i := range slots {
+				if slots[i].pending() {
+					slots[i].idle = true
+				}
+			}
+		case ok:
+			// 用Set而不是类型断言，T是接口类型且收到nil时也能正确处理
+			slots[i-caseInput] = slot[T]{full: true}
+			reflect.ValueOf(&slots[i-caseInput].value).Elem().Set(v)
+		default:
+			slots[i-caseInput].closed = true
+		}
+	}
+}
+
+// stopTimer 停止计时器并清空可能已经到期的值，之后可以安全地 Reset
+func stopTimer(t *time.Timer) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// choose 返回下一个要交付的输入，没有预读的元素时返回-1
+func (m *PriorityMux[T]) choose(slots []slot[T], current []int) int {
+	if m.weights == nil {
+		for i := range slots {
+			if slots[i].full {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// 平滑加权轮询：每个候选的当前值加上权重，取最大者
+	best := -1
+	for i := range slots {
+		if !slots[i].full {
+			continue
+		}
+		if best < 0 || current[i]+m.weights[i] > current[best]+m.weights[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// commit 在chosen交付后更新加权轮询的当前值
+func (m *PriorityMux[T]) commit(slots []slot[T], current []int, chosen int) {
+	if m.weights == nil {
+		return
+	}
+	total := 0
+	for i := range slots {
+		if slots[i].full {
+			current[i] += m.weights[i]
+			total += m.weights[i]
+		}
+	}
+	current[chosen] -= total
+}
+
+func allClosed[T any](slots []slot[T]) bool {
+	for _, s := range slots {
+		if !s.closed {
+			return false
+		}
+	}
+	return true
+}
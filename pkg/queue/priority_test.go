@@ -0,0 +1,176 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// flood 不停地向返回的Channel发送tag，直到ctx取消
+func flood(ctx context.Context, tag string, buffer int) <-chan string {
+	ch := make(chan string, buffer)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case ch <- tag:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// TestStrictMuxBacklog 测试低优先级积压时，已就绪的高优先级元素全部先交付
+func TestStrictMuxBacklog(t *testing.T) {
+	high := make(chan int, 10)
+	low := make(chan int, 100)
+	for i := 0; i < 100; i++ {
+		low <- -i
+	}
+	for i := 1; i <= 10; i++ {
+		high <- i
+	}
+	close(high)
+	close(low)
+
+	m := StrictMux(context.Background(), high, low)
+	var got []int
+	for v := range m.Out() {
+		got = append(got, v)
+	}
+
+	if len(got) != 110 {
+		t.Fatalf("收到 %d 个元素, 期望110", len(got))
+	}
+	for i := 0; i < 10; i++ {
+		if got[i] != i+1 {
+			t.Fatalf("前10个应当全部是高优先级, 第%d个是 %d", i, got[i])
+		}
+	}
+	if d := m.Delivered(); d[0] != 10 || d[1] != 100 {
+		t.Errorf("交付统计 %v", d)
+	}
+}
+
+// TestStrictMuxNotStuckBehindLow 测试低优先级持续涌入时，新到的高优先级元素是下一个被交付的
+func TestStrictMuxNotStuckBehindLow(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	urgent := make(chan string)
+	m := StrictMux(ctx, urgent, flood(ctx, "low", 16))
+
+	for round := 0; round < 20; round++ {
+		for i := 0; i < 5; i++ {
+			if v := <-m.Out(); v != "low" {
+				t.Fatalf("第%d轮: 没有高优先级时收到 %q", round, v)
+			}
+		}
+		// 发送完成说明多路复用器已经读到了它
+		urgent <- "high"
+		if v := <-m.Out(); v != "high" {
+			t.Fatalf("第%d轮: 高优先级被排在低优先级后面, 收到 %q", round, v)
+		}
+	}
+}
+
+// TestStrictMuxLiveProducers 测试高优先级的生产者一直在发送时，低优先级拿不到任何轮次
+//
+// 高优先级的槽每次交付后都会短暂为空，多路复用器必须等它补充，而不是趁机交付低优先级
+func TestStrictMuxLiveProducers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m := StrictMux(ctx, flood(ctx, "high", 0), flood(ctx, "low", 0))
+	// 两个生产者可能还没启动，第一个元素的来源不确定
+	<-m.Out()
+	for i := 0; i < 500; i++ {
+		if v := <-m.Out(); v != "high" {
+			t.Fatalf("第%d个元素来自 %q", i, v)
+		}
+	}
+}
+
+// TestWeightedMuxShares 测试生产者一直在发送时加权公平按权重分配，低优先级不会饿死
+//
+// 非缓冲的生产者在每次被读走之后都要重新调度才能补充，
+// 多路复用器必须等它们补充后再做决定，否则高权重的输入会丢掉轮次
+func TestWeightedMuxShares(t *testing.T) {
+	for _, buffer := range []int{0, 16} {
+		t.Run(fmt.Sprintf("buffer=%d", buffer), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			m := WeightedMux(ctx, []int{6, 3, 1},
+				flood(ctx, "high", buffer), flood(ctx, "mid", buffer), flood(ctx, "low", buffer))
+
+			counts := make(map[string]int)
+			for i := 0; i < 1000; i++ {
+				counts[<-m.Out()]++
+			}
+
+			// 三个输入一直有数据，平滑加权轮询每10个交付中正好是 6:3:1
+			want := map[string]int{"high": 600, "mid": 300, "low": 100}
+			for tag, n := range want {
+				if diff := counts[tag] - n; diff < -10 || diff > 10 {
+					t.Errorf("%s 交付 %d 个, 期望约 %d (全部: %v)", tag, counts[tag], n, counts)
+				}
+			}
+		})
+	}
+}
+
+// TestWeightedMuxIdleInputs 测试只有一个输入有数据时它独占输出
+func TestWeightedMuxIdleInputs(t *testing.T) {
+	high := make(chan int)
+	low := make(chan int, 5)
+	for i := 0; i < 5; i++ {
+		low <- i
+	}
+	close(low)
+
+	m := WeightedMux(context.Background(), []int{10, 1}, high, low)
+	for i := 0; i < 5; i++ {
+		select {
+		case v := <-m.Out():
+			if v != i {
+				t.Errorf("收到 %d, 期望 %d", v, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("高优先级空闲时低优先级不应等待")
+		}
+	}
+	close(high)
+	if _, ok := <-m.Out(); ok {
+		t.Error("所有输入关闭后输出应当被关闭")
+	}
+}
+
+// TestPriorityMuxCancel 测试取消后内部goroutine退出
+func TestPriorityMuxCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	StrictMux(ctx, make(chan error), make(chan error))
+	WeightedMux(ctx, []int{2, 1}, make(chan error), make(chan error))
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	checkGoroutineLeak(t, baseline)
+}
+
+// TestPriorityMuxNilInterface 测试元素类型是接口时可以传递nil
+func TestPriorityMuxNilInterface(t *testing.T) {
+	errs := make(chan error, 1)
+	errs <- nil
+	close(errs)
+
+	m := StrictMux(context.Background(), errs)
+	if err, ok := <-m.Out(); !ok || err != nil {
+		t.Errorf("期望收到nil, 实际 %v, %v", err, ok)
+	}
+}
@@ -0,0 +1,387 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// ErrClosed 在队列关闭后继续放入，或关闭且取空后继续取出时返回
+var ErrClosed = errors.New("queue: closed")
+
+// Option 配置 Queue 的可选参数
+type Option func(*config)
+
+type config struct {
+	high, low  float64
+	onPressure func(Pressure)
+
+	overflow    OverflowPolicy
+	sampleEvery int
+	spillDir    string
+}
+
+// WithWatermarks 设置背压的高低水位（占用比例，0到1之间），例如 0.8 和 0.3
+// 占用达到high时进入暂停状态，回落到low时恢复；两个阈值之间保持原状态，避免频繁切换
+func WithWatermarks(high, low float64) Option {
+	return func(c *config) {
+		if high > 0 && high <= 1 && low >= 0 && low < high {
+			c.high, c.low = high, low
+		}
+	}
+}
+
+// WithPressureCallback 设置背压状态变化时的回调
+// 回调在持有队列锁时同步调用，保证按发生顺序执行；回调中不能调用队列的方法，并且应当尽快返回
+func WithPressureCallback(fn func(Pressure)) Option {
+	return func(c *config) {
+		c.onPressure = fn
+	}
+}
+
+// Pressure 是一次背压状态变化
+type Pressure struct {
+	Paused bool // true表示达到高水位，生产者应当暂停
+	Len    int
+	Cap    int
+}
+
+// Queue 是有界的FIFO队列，支持context、关闭和背压通知
+//
+// bufferOverflowExperiment 中生产者用 select+default 探测缓冲区是否已满，
+// 满了就睡500ms再试。Queue 把"快满了"和"可以继续了"作为事件通知出来：
+// 生产者可以注册回调、订阅Channel，或者在 WaitResume 上等待，不需要轮询。
+//
+// 不能阻塞的生产者（例如 practicalUseCase 中写日志的请求处理函数）
+// 可以用 WithOverflow 等选项改为丢弃、采样或溢出到磁盘，Counters 记录丢弃和溢出的数量。
+type Queue[T any] struct {
+	cfg       config
+	highCount int // 达到这个元素数时暂停
+	lowCount  int // 回落到这个元素数时恢复
+
+	mu      sync.Mutex
+	buf     *ring[T]
+	closed  bool
+	changed chan struct{} // 每次状态变化时关闭并替换，用于唤醒等待者
+	paused  bool
+	subs    map[int]chan Pressure
+	nextSub int
+
+	counters Counters
+	offered  int           // Sample 策略下队列满时收到的元素数
+	spill    *spillFile[T] // Spill 策略下的磁盘段，没有溢出时为nil
+	spillErr error         // 取出元素之后读取溢出文件失败的错误，由下一次 Get 返回
+}
+
+// New 创建一个容量为capacity的队列，capacity小于1时按1处理
+func New[T any](capacity int, opts ...Option) *Queue[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	q := &Queue[T]{
+		cfg:     cfg,
+		buf:     newRing[T](capacity),
+		changed: make(chan struct{}),
+		subs:    make(map[int]chan Pressure),
+	}
+	if cfg.high > 0 {
+		q.highCount = int(math.Ceil(cfg.high * float64(capacity)))
+		q.lowCount = int(math.Floor(cfg.low * float64(capacity)))
+	}
+	return q
+}
+
+// Put 放入一个元素，队列满时按溢出策略处理：
+// Block 阻塞直到有空间、ctx取消或队列关闭；其他策略不会阻塞，
+// 被丢弃的元素不算错误，只记入 Counters；Spill 写磁盘失败时返回错误
+func (q *Queue[T]) Put(ctx context.Context, v T) error {
+	q.mu.Lock()
+	for {
+		if q.closed {
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		// 磁盘上还有元素时新元素也要写磁盘，保证先进先出
+		if q.spill != nil && q.spill.pending > 0 {
+			break
+		}
+		if !q.buf.full() {
+			q.buf.push(v)
+			q.counters.Accepted++
+			q.update()
+			q.mu.Unlock()
+			return nil
+		}
+		if q.cfg.overflow != Block {
+			break
+		}
+		if err := q.wait(ctx); err != nil {
+			return err
+		}
+	}
+	defer q.mu.Unlock()
+	return q.overflow(v)
+}
+
+// overflow 按策略处理放不下的元素，调用方必须持有锁
+func (q *Queue[T]) overflow(v T) error {
+	switch q.cfg.overflow {
+	case DropNewest:
+		q.counters.Dropped++
+	case DropOldest:
+		q.buf.pop()
+		q.buf.push(v)
+		q.counters.Accepted++
+		q.counters.Dropped++
+		q.update()
+	case Sample:
+		q.offered++
+		q.counters.Dropped++
+		if q.offered%q.cfg.sampleEvery == 0 {
+			q.buf.pop()
+			q.buf.push(v)
+			q.counters.Accepted++
+			q.update()
+		}
+	case Spill:
+		if q.spill == nil {
+			spill, err := createSpill[T](q.cfg.spillDir)
+			if err != nil {
+				return fmt.Errorf("queue: 创建溢出文件失败: %w", err)
+			}
+			q.spill = spill
+		}
+		if err := q.spill.write(v); err != nil {
+			return err
+		}
+		q.counters.Accepted++
+		q.counters.Spilled++
+	}
+	return nil
+}
+
+// Get 取出一个元素，队列空时阻塞；队列关闭且已取空时返回 ErrClosed
+func (q *Queue[T]) Get(ctx context.Context) (T, error) {
+	q.mu.Lock()
+	if err := q.spillErr; err != nil {
+		q.spillErr = nil
+		q.mu.Unlock()
+		var zero T
+		return zero, err
+	}
+	for q.buf.Len() == 0 {
+		if q.spill != nil && q.spill.pending > 0 {
+			break
+		}
+		if q.closed {
+			q.mu.Unlock()
+			var zero T
+			return zero, ErrClosed
+		}
+		if err := q.wait(ctx); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	if q.buf.Len() == 0 {
+		// 内存已取空，元素都在磁盘上
+		if err := q.refill(); err != nil {
+			q.mu.Unlock()
+			var zero T
+			return zero, err
+		}
+	}
+	v := q.buf.pop()
+	q.counters.Taken++
+	// 用磁盘上的元素补满内存；v已经取出，失败时由下一次 Get 返回错误
+	q.spillErr = q.refill()
+	q.update()
+	q.mu.Unlock()
+	return v, nil
+}
+
+// refill 把磁盘上的元素按顺序读回内存，直到内存满或磁盘读空，调用方必须持有锁
+// 磁盘读空后删除溢出文件，下次溢出时重新创建。
+// 读取失败说明文件已经损坏，解码器无法从错误位置恢复，
+// 剩余的元素计入 Dropped 并删除文件，而不是在每次 Get 时重试同一个位置
+func (q *Queue[T]) refill() error {
+	if q.spill == nil {
+		return nil
+	}
+	for q.spill.pending > 0 && !q.buf.full() {
+		v, err := q.spill.read()
+		if err != nil {
+			q.counters.Dropped += int64(q.spill.pending)
+			q.spill.remove()
+			q.spill = nil
+			return err
+		}
+		q.buf.push(v)
+	}
+	if q.spill.pending == 0 {
+		q.spill.remove()
+		q.spill = nil
+	}
+	return nil
+}
+
+// Totals 返回累计放入和取出的元素数，供 Registry 计算速率
+func (q *Queue[T]) Totals() (sent, received int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.counters.Accepted, q.counters.Taken
+}
+
+// Counters 返回溢出统计
+func (q *Queue[T]) Counters() Counters {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	c := q.counters
+	if q.spill != nil {
+		c.OnDisk = q.spill.pending
+	}
+	return c
+}
+
+// wait 释放锁并等待下一次状态变化，返回nil时重新持有锁，返回错误时不持有锁
+func (q *Queue[T]) wait(ctx context.Context) error {
+	changed := q.changed
+	q.mu.Unlock()
+
+	select {
+	case <-changed:
+		q.mu.Lock()
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update 在元素数变化后调用：唤醒等待者并检查水位，调用方必须持有锁
+func (q *Queue[T]) update() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+
+	if q.highCount == 0 {
+		return
+	}
+	n := q.buf.Len()
+	switch {
+	case !q.paused && n >= q.highCount:
+		q.paused = true
+	case q.paused && n <= q.lowCount:
+		q.paused = false
+	default:
+		return
+	}
+
+	p := Pressure{Paused: q.paused, Len: n, Cap: q.buf.Cap()}
+	if q.cfg.onPressure != nil {
+		q.cfg.onPressure(p)
+	}
+	for _, ch := range q.subs {
+		// 订阅者只关心最新状态，来不及读取的旧状态直接替换
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- p
+	}
+}
+
+// Close 关闭队列，之后 Put 返回 ErrClosed，Get 取完剩余元素后返回 ErrClosed
+// 订阅Channel也会被关闭；多次调用是安全的
+// 溢出文件的写端在关闭时释放，磁盘上的剩余元素取完后文件被删除
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	if q.spill != nil {
+		if err := q.spill.closeWriter(); err != nil {
+			// 写入没有落盘，磁盘上的元素已经不完整
+			q.counters.Dropped += int64(q.spill.pending)
+			q.spill.remove()
+			q.spill = nil
+			q.spillErr = err
+		}
+	}
+	close(q.changed)
+	q.changed = make(chan struct{})
+	for id, ch := range q.subs {
+		close(ch)
+		delete(q.subs, id)
+	}
+}
+
+// Len 返回队列中的元素数
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.buf.Len()
+}
+
+// Cap 返回队列容量
+func (q *Queue[T]) Cap() int {
+	return q.buf.Cap()
+}
+
+// Paused 报告队列是否处于高水位暂停状态
+func (q *Queue[T]) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.paused
+}
+
+// WaitResume 在暂停状态下阻塞，直到占用回落到低水位、ctx取消或队列关闭
+// 生产者在每次 Put 之前调用它，就能在高水位时主动停下来，而不是一直占满队列
+func (q *Queue[T]) WaitResume(ctx context.Context) error {
+	q.mu.Lock()
+	for q.paused {
+		if q.closed {
+			q.mu.Unlock()
+			return ErrClosed
+		}
+		if err := q.wait(ctx); err != nil {
+			return err
+		}
+	}
+	q.mu.Unlock()
+	return nil
+}
+
+// Subscribe 订阅背压状态变化，返回的Channel总是保留最新的一次变化
+// 调用cancel取消订阅并关闭Channel；队列关闭时Channel也会被关闭
+func (q *Queue[T]) Subscribe() (<-chan Pressure, func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ch := make(chan Pressure, 1)
+	if q.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	id := q.nextSub
+	q.nextSub++
+	q.subs[id] = ch
+
+	return ch, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		if _, ok := q.subs[id]; ok {
+			close(ch)
+			delete(q.subs, id)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestQueueFIFOAndClose 测试先进先出，关闭后取完剩余元素再返回 ErrClosed
+func TestQueueFIFOAndClose(t *testing.T) {
+	ctx := context.Background()
+	q := New[int](3)
+
+	for i := 1; i <= 3; i++ {
+		if err := q.Put(ctx, i); err != nil {
+			t.Fatalf("Put(%d) 返回错误: %v", i, err)
+		}
+	}
+	q.Close()
+	q.Close() // 重复关闭不应panic
+
+	if err := q.Put(ctx, 4); err != ErrClosed {
+		t.Errorf("关闭后 Put 期望 ErrClosed, 实际 %v", err)
+	}
+	for want := 1; want <= 3; want++ {
+		if v, err := q.Get(ctx); err != nil || v != want {
+			t.Errorf("Get 返回 %d, %v, 期望 %d", v, err, want)
+		}
+	}
+	if _, err := q.Get(ctx); err != ErrClosed {
+		t.Errorf("取空后期望 ErrClosed, 实际 %v", err)
+	}
+}
+
+// TestQueueBlocking 测试队列满时 Put 阻塞，取走一个后继续；ctx取消时放弃
+func TestQueueBlocking(t *testing.T) {
+	ctx := context.Background()
+	q := New[string](1)
+	q.Put(ctx, "a")
+
+	done := make(chan error)
+	go func() { done <- q.Put(ctx, "b") }()
+
+	select {
+	case <-done:
+		t.Fatal("队列满时 Put 应当阻塞")
+	case <-time.After(10 * time.Millisecond):
+	}
+	if v, _ := q.Get(ctx); v != "a" {
+		t.Errorf("Get 返回 %q, 期望 a", v)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Put 返回错误: %v", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := q.Put(timeout, "c"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("期望 DeadlineExceeded, 实际 %v", err)
+	}
+	if q.Len() != 1 {
+		t.Errorf("超时的 Put 不应放入元素, Len=%d", q.Len())
+	}
+}
+
+// TestQueueWatermarks 测试80%暂停、30%恢复，中间区域保持原状态
+func TestQueueWatermarks(t *testing.T) {
+	ctx := context.Background()
+	var events []Pressure
+	q := New[int](10, WithWatermarks(0.8, 0.3), WithPressureCallback(func(p Pressure) {
+		events = append(events, p)
+	}))
+	sub, unsubscribe := q.Subscribe()
+	defer unsubscribe()
+
+	for i := 0; i < 7; i++ {
+		q.Put(ctx, i)
+	}
+	if q.Paused() || len(events) != 0 {
+		t.Fatalf("7/10 不应暂停, 事件 %v", events)
+	}
+
+	q.Put(ctx, 7)
+	if !q.Paused() {
+		t.Fatal("8/10 应当暂停")
+	}
+	if p := <-sub; !p.Paused || p.Len != 8 || p.Cap != 10 {
+		t.Errorf("订阅收到 %+v", p)
+	}
+
+	for q.Len() > 4 {
+		q.Get(ctx)
+	}
+	if !q.Paused() {
+		t.Error("4/10 在两个水位之间，应保持暂停")
+	}
+	q.Get(ctx)
+	if q.Paused() {
+		t.Error("3/10 应当恢复")
+	}
+	if p := <-sub; p.Paused || p.Len != 3 {
+		t.Errorf("订阅收到 %+v", p)
+	}
+
+	want := []Pressure{{Paused: true, Len: 8, Cap: 10}, {Paused: false, Len: 3, Cap: 10}}
+	if len(events) != 2 || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("回调事件 %v, 期望 %v", events, want)
+	}
+}
+
+// TestQueueCooperativeProducer 测试生产者在 WaitResume 上等待，队列占用不超过高水位
+func TestQueueCooperativeProducer(t *testing.T) {
+	const n = 200
+	ctx := context.Background()
+	q := New[int](10, WithWatermarks(0.8, 0.3))
+
+	var maxLen int
+	var mu sync.Mutex
+	go func() {
+		for i := 0; i < n; i++ {
+			if err := q.WaitResume(ctx); err != nil {
+				t.Errorf("WaitResume 返回错误: %v", err)
+				return
+			}
+			q.Put(ctx, i)
+			mu.Lock()
+			maxLen = max(maxLen, q.Len())
+			mu.Unlock()
+		}
+		q.Close()
+	}()
+
+	next := 0
+	for {
+		v, err := q.Get(ctx)
+		if err == ErrClosed {
+			break
+		}
+		if v != next {
+			t.Fatalf("收到 %d, 期望 %d", v, next)
+		}
+		next++
+		time.Sleep(50 * time.Microsecond) // 慢消费者
+	}
+
+	if next != n {
+		t.Errorf("收到 %d 个元素, 期望 %d", next, n)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if maxLen > 8 {
+		t.Errorf("最大占用 %d, 协作的生产者不应超过高水位8", maxLen)
+	}
+}
+
+// TestQueueCloseWakesWaiters 测试关闭队列唤醒所有等待者并关闭订阅
+func TestQueueCloseWakesWaiters(t *testing.T) {
+	ctx := context.Background()
+	q := New[int](2, WithWatermarks(0.5, 0))
+	sub, _ := q.Subscribe()
+	q.Put(ctx, 1)
+	if p := <-sub; !p.Paused {
+		t.Fatalf("1/2 应当暂停, 实际 %+v", p)
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- q.WaitResume(ctx) }()
+	go func() {
+		q.Put(ctx, 2)
+		errs <- q.Put(ctx, 3)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	q.Close()
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != ErrClosed {
+			t.Errorf("关闭后期望 ErrClosed, 实际 %v", err)
+		}
+	}
+	if _, ok := <-sub; ok {
+		t.Error("关闭后订阅Channel应当被关闭")
+	}
+}
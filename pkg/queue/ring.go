@@ -0,0 +1,49 @@
+package queue
+
+// ring 是容量可以调整的环形缓冲区，不是并发安全的
+type ring[T any] struct {
+	buf  []T
+	head int // 队首元素的下标
+	n    int // 元素个数
+}
+
+func newRing[T any](capacity int) *ring[T] {
+	return &ring[T]{buf: make([]T, capacity)}
+}
+
+func (r *ring[T]) Len() int { return r.n }
+
+func (r *ring[T]) Cap() int { return len(r.buf) }
+
+func (r *ring[T]) full() bool { return r.n == len(r.buf) }
+
+// push 把v放到队尾，调用方负责保证缓冲区未满
+func (r *ring[T]) push(v T) {
+	r.buf[(r.head+r.n)%len(r.buf)] = v
+	r.n++
+}
+
+// peek 返回队首元素，调用方负责保证缓冲区非空
+func (r *ring[T]) peek() T {
+	return r.buf[r.head]
+}
+
+// pop 取出队首元素，调用方负责保证缓冲区非空
+func (r *ring[T]) pop() T {
+	var zero T
+	v := r.buf[r.head]
+	r.buf[r.head] = zero // 释放引用，避免已出队的元素无法被回收
+	r.head = (r.head + 1) % len(r.buf)
+	r.n--
+	return v
+}
+
+// resize 把容量调整为capacity，capacity不能小于当前元素个数
+func (r *ring[T]) resize(capacity int) {
+	buf := make([]T, capacity)
+	for i := 0; i < r.n; i++ {
+		buf[i] = r.buf[(r.head+i)%len(r.buf)]
+	}
+	r.buf = buf
+	r.head = 0
+}
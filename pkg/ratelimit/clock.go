@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 抽象时间来源，测试中可以替换为 FakeClock
+type Clock interface {
+	Now() time.Time
+	// After 在d之后向返回的Channel发送当前时间，与 time.After 相同
+	After(d time.Duration) <-chan time.Time
+	// NewTimer 创建一个在d之后触发的计时器，循环中需要反复等待时用它代替 After
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 是可以重置的计时器，对应 time.Timer
+type Timer interface {
+	// C 返回计时器触发时接收时间的Channel
+	C() <-chan time.Time
+	// Reset 让计时器在d之后触发，尚未触发或尚未读取的时间被丢弃
+	Reset(d time.Duration)
+	// Stop 停止计时器
+	Stop()
+}
+
+// RealClock 使用系统时间
+type RealClock struct{}
+
+// Now 返回 time.Now()
+func (RealClock) Now() time.Time { return time.Now() }
+
+// After 返回 time.After(d)
+func (RealClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// NewTimer 返回包装 time.NewTimer(d) 的计时器
+func (RealClock) NewTimer(d time.Duration) Timer { return realTimer{time.NewTimer(d)} }
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time { return r.t.C }
+
+// Reset 先停止并清空Channel，这样不管旧的计时器是否已经触发，Reset之后都只会收到新的时间
+func (r realTimer) Reset(d time.Duration) {
+	r.Stop()
+	r.t.Reset(d)
+}
+
+func (r realTimer) Stop() {
+	if !r.t.Stop() {
+		select {
+		case <-r.t.C:
+		default:
+		}
+	}
+}
+
+// FakeClock 是手动推进的时钟，用于编写确定性的测试
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// NewFakeClock 创建一个从start开始的假时钟
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now 返回假时钟的当前时间
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After 返回一个在假时钟推进d之后才会收到时间的Channel
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	at := c.now.Add(d)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, &fakeWaiter{at: at, ch: ch})
+	return ch
+}
+
+// NewTimer 返回一个在假时钟推进d之后触发的计时器
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// fakeTimer 是 FakeClock 的计时器，等待期间占用一个waiter
+type fakeTimer struct {
+	c  *FakeClock
+	ch chan time.Time
+	w  *fakeWaiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Reset(d time.Duration) {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	t.stop()
+	if d <= 0 {
+		t.ch <- t.c.now
+		return
+	}
+	t.w = &fakeWaiter{at: t.c.now.Add(d), ch: t.ch}
+	t.c.waiters = append(t.c.waiters, t.w)
+}
+
+func (t *fakeTimer) Stop() {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	t.stop()
+}
+
+// stop 移除等待中的waiter并清空Channel，调用方必须持有时钟的锁
+func (t *fakeTimer) stop() {
+	for i, w := range t.c.waiters {
+		if w == t.w {
+			t.c.waiters = append(t.c.waiters[:i], t.c.waiters[i+1:]...)
+			break
+		}
+	}
+	t.w = nil
+	select {
+	case <-t.ch:
+	default:
+	}
+}
+
+// Advance 把时钟推进d，并触发所有到期的 After
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.at.After(c.now) {
+			w.ch <- c.now
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
+
+// Waiters 返回还在等待的 After 数量，测试中用来确认goroutine已经进入等待
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
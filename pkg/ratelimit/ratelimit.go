@@ -0,0 +1,202 @@
+// Package ratelimit 提供令牌桶和漏桶两种限流器。
+//
+// pipeline_pattern.go 的扩展练习"实现带限流的Pipeline"要求控制每个阶段的
+// 最大处理速率。两种限流器都实现 Limiter 接口，可以通过 pipeline.Throttle
+// 包装在任意阶段之前。所有等待都接收 context.Context，取消后立即返回。
+//
+//   - TokenBucket：按固定速率补充令牌，最多积攒 burst 个，允许短时突发
+//   - LeakyBucket：按固定间隔放行，输出完全平滑，排队超过容量时直接拒绝
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrBucketFull 在漏桶排队已满时返回
+var ErrBucketFull = errors.New("ratelimit: bucket is full")
+
+// Limiter 是限流器的通用接口
+type Limiter interface {
+	// Allow 不等待，能立即通过时返回true
+	Allow() bool
+	// Wait 等待直到允许通过，ctx取消时返回ctx.Err()
+	Wait(ctx context.Context) error
+}
+
+// sleep 用clock等待d，ctx取消时返回false
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := clock.NewTimer(d)
+	defer t.Stop() // 取消时及时释放计时器
+	select {
+	case <-t.C():
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// TokenBucket 是令牌桶限流器
+type TokenBucket struct {
+	rate  float64 // 每秒补充的令牌数
+	burst float64 // 桶容量
+	clock Clock
+
+	mu     sync.Mutex
+	tokens float64 // 可以为负数，表示已经预约给等待者的令牌
+	last   time.Time
+}
+
+// NewTokenBucket 创建令牌桶：每秒补充rate个令牌，最多积攒burst个，初始为满
+// rate必须为正，否则panic
+// clock为nil时使用系统时间
+func NewTokenBucket(rate float64, burst int, clock Clock) *TokenBucket {
+	if !(rate > 0) {
+		panic(fmt.Sprintf("ratelimit: 令牌桶的速率必须为正, 实际 %v", rate))
+	}
+	if clock == nil {
+		clock = RealClock{}
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		clock:  clock,
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// refill 按经过的时间补充令牌，调用方必须持有mu
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += elapsed * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// Allow 有令牌时取走一个并返回true
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(b.clock.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// Wait 预约一个令牌并等到它可用，ctx取消时归还预约
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	b.refill(b.clock.Now())
+	b.tokens--
+	var wait time.Duration
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if !sleep(ctx, b.clock, wait) {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+	return nil
+}
+
+// LeakyBucket 是漏桶限流器：请求按固定间隔依次放行
+type LeakyBucket struct {
+	interval time.Duration // 两次放行之间的间隔
+	capacity int           // 最多允许排队的请求数
+	clock    Clock
+
+	mu   sync.Mutex
+	next time.Time // 下一个可以放行的时间
+}
+
+// NewLeakyBucket 创建漏桶：每秒放行rate个请求，最多capacity个请求排队
+// rate必须为正，否则panic
+// clock为nil时使用系统时间
+func NewLeakyBucket(rate float64, capacity int, clock Clock) *LeakyBucket {
+	if !(rate > 0) {
+		panic(fmt.Sprintf("ratelimit: 漏桶的速率必须为正, 实际 %v", rate))
+	}
+	if clock == nil {
+		clock = RealClock{}
+	}
+	if capacity < 0 {
+		capacity = 0
+	}
+	return &LeakyBucket{
+		interval: time.Duration(float64(time.Second) / rate),
+		capacity: capacity,
+		clock:    clock,
+		next:     clock.Now(),
+	}
+}
+
+// Allow 当前就能放行时返回true，不排队
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Wait 排队等待放行，排队人数超过容量时返回 ErrBucketFull
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	now := b.clock.Now()
+	at := b.next
+	if at.Before(now) {
+		at = now
+	}
+	wait := at.Sub(now)
+	if wait > time.Duration(b.capacity)*b.interval {
+		b.mu.Unlock()
+		return ErrBucketFull
+	}
+	b.next = at.Add(b.interval)
+	b.mu.Unlock()
+
+	if !sleep(ctx, b.clock, wait) {
+		// 取消的请求让出自己的位置：如果它是最后一个排队的，把时间表往回拨
+		b.mu.Lock()
+		if b.next.Equal(at.Add(b.interval)) {
+			b.next = at
+		}
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// waitForWaiters 等待有n个goroutine阻塞在假时钟上
+func waitForWaiters(t *testing.T, c *FakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if c.Waiters() == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待 %d 个goroutine进入等待超时, 当前 %d", n, c.Waiters())
+}
+
+// TestTokenBucketBurst 测试令牌桶允许突发，然后按速率补充
+func TestTokenBucketBurst(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	b := NewTokenBucket(10, 3, clock) // 每秒10个，突发3个
+
+	for i := 0; i < 3; i++ {
+		if !b.Allow() {
+			t.Fatalf("第 %d 次 Allow 应该成功(突发)", i+1)
+		}
+	}
+	if b.Allow() {
+		t.Fatal("令牌用完后 Allow 应该失败")
+	}
+
+	clock.Advance(100 * time.Millisecond) // 补充1个
+	if !b.Allow() {
+		t.Fatal("100ms后应该补充1个令牌")
+	}
+	if b.Allow() {
+		t.Fatal("只补充了1个令牌")
+	}
+
+	clock.Advance(time.Hour) // 最多积攒到burst
+	for i := 0; i < 3; i++ {
+		b.Allow()
+	}
+	if b.Allow() {
+		t.Fatal("令牌不应超过burst")
+	}
+}
+
+// TestTokenBucketWait 测试Wait按速率放行
+func TestTokenBucketWait(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	b := NewTokenBucket(5, 1, clock) // 每200ms一个
+
+	ctx := context.Background()
+	if err := b.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error)
+	go func() { done <- b.Wait(ctx) }()
+	waitForWaiters(t, clock, 1)
+
+	clock.Advance(199 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("不到200ms就放行了")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	clock.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTokenBucketWaitCancel 测试取消等待会归还预约的令牌
+func TestTokenBucketWaitCancel(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	b := NewTokenBucket(1, 1, clock)
+	b.Allow()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.Wait(ctx) }()
+	waitForWaiters(t, clock, 1)
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("期望 Canceled, 实际 %v", err)
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("取消后计时器应被停止, 还有 %d 个等待者", n)
+	}
+
+	// 被取消的预约已归还，1秒后应该有1个令牌
+	clock.Advance(time.Second)
+	if !b.Allow() {
+		t.Error("取消的预约没有归还令牌")
+	}
+}
+
+// TestLeakyBucketSmooth 测试漏桶按固定间隔放行，不允许突发
+func TestLeakyBucketSmooth(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	b := NewLeakyBucket(10, 5, clock) // 每100ms一个
+
+	if !b.Allow() {
+		t.Fatal("第一次应该放行")
+	}
+	if b.Allow() {
+		t.Fatal("漏桶不允许突发")
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !b.Allow() {
+		t.Fatal("100ms后应该放行")
+	}
+}
+
+// TestLeakyBucketQueue 测试排队的请求依次放行，排满后拒绝
+func TestLeakyBucketQueue(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	b := NewLeakyBucket(10, 2, clock) // 每100ms一个，最多排队2个
+	ctx := context.Background()
+
+	if err := b.Wait(ctx); err != nil { // 立即放行
+		t.Fatal(err)
+	}
+
+	results := make(chan int, 2)
+	for i := 1; i <= 2; i++ {
+		go func(id int) {
+			if err := b.Wait(ctx); err == nil {
+				results <- id
+			}
+		}(i)
+		waitForWaiters(t, clock, i)
+	}
+
+	if err := b.Wait(ctx); err != ErrBucketFull {
+		t.Fatalf("排队已满时期望 ErrBucketFull, 实际 %v", err)
+	}
+
+	clock.Advance(100 * time.Millisecond)
+	<-results
+	if len(results) != 0 {
+		t.Fatal("100ms内只应该放行1个")
+	}
+	clock.Advance(100 * time.Millisecond)
+	<-results
+}
+
+// TestWaitCancelledContext 测试已取消的ctx立即返回
+func TestWaitCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, l := range map[string]Limiter{
+		"TokenBucket": NewTokenBucket(1, 1, nil),
+		"LeakyBucket": NewLeakyBucket(1, 1, nil),
+	} {
+		if err := l.Wait(ctx); err != context.Canceled {
+			t.Errorf("%s: 期望 Canceled, 实际 %v", name, err)
+		}
+	}
+}
+
+// TestFakeClockTimerReset 测试假时钟的计时器重置后只在新的时间触发
+func TestFakeClockTimerReset(t *testing.T) {
+	clock := NewFakeClock(epoch)
+	timer := clock.NewTimer(time.Second)
+
+	timer.Reset(3 * time.Second)
+	if n := clock.Waiters(); n != 1 {
+		t.Fatalf("重置后应只有1个waiter, 实际 %d", n)
+	}
+	clock.Advance(2 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("重置前的时间不应触发")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(epoch.Add(3 * time.Second)) {
+			t.Errorf("触发时间 %v", at)
+		}
+	default:
+		t.Fatal("到达重置后的时间应当触发")
+	}
+
+	timer.Reset(time.Second)
+	timer.Stop()
+	clock.Advance(time.Hour)
+	select {
+	case <-timer.C():
+		t.Fatal("停止后不应触发")
+	default:
+	}
+	if n := clock.Waiters(); n != 0 {
+		t.Errorf("停止后还有 %d 个waiter", n)
+	}
+}
+
+// TestInvalidRatePanics 测试速率不为正时构造函数panic，而不是得到无穷大或负数的间隔
+func TestInvalidRatePanics(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		for name, build := range map[string]func(){
+			"令牌桶": func() { NewTokenBucket(rate, 1, nil) },
+			"漏桶":  func() { NewLeakyBucket(rate, 1, nil) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("%s 速率 %v 应当panic", name, rate)
+					}
+				}()
+				build()
+			}()
+		}
+	}
+}
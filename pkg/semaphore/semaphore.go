@@ -0,0 +1,138 @@
+// Package semaphore 提供一个带权重的信号量。
+//
+// week1/day1 的 goroutinePoolDemo 用 make(chan struct{}, poolSize) 充当信号量，
+// 这种写法每次只能获取1个单位，也不能配合超时或context使用。
+// Semaphore 支持一次获取多个单位、context取消和非阻塞尝试，
+// 并按先来先服务(FIFO)的顺序唤醒等待者，大请求不会被小请求饿死。
+package semaphore
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// waiter 是一个排队等待的获取请求
+type waiter struct {
+	n     int64
+	ready chan struct{} // 获取成功时关闭
+}
+
+// Semaphore 是带权重的信号量，零值不可用，请使用 New 创建
+type Semaphore struct {
+	size    int64
+	cur     int64
+	mu      sync.Mutex
+	waiters list.List
+}
+
+// New 创建一个总容量为 n 的信号量
+func New(n int64) *Semaphore {
+	return &Semaphore{size: n}
+}
+
+// Acquire 获取 n 个单位，容量不足时阻塞，直到获取成功或ctx取消
+// 成功返回nil；ctx取消时返回ctx.Err()，且不占用任何容量
+// n 超过总容量时永远无法满足，只能等待ctx取消；n 不为正时panic
+func (s *Semaphore) Acquire(ctx context.Context, n int64) error {
+	checkWeight(n)
+	s.mu.Lock()
+	// 只有没有人排队时才能直接获取，保证FIFO
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		s.mu.Unlock()
+		return nil
+	}
+
+	if n > s.size {
+		// 不可能满足的请求，不入队以免阻塞后面的请求
+		s.mu.Unlock()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	ready := make(chan struct{})
+	elem := s.waiters.PushBack(waiter{n: n, ready: ready})
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+
+	case <-ctx.Done():
+		s.mu.Lock()
+		select {
+		case <-ready:
+			// 取消的同时刚好获取成功，视为成功
+			s.mu.Unlock()
+			return nil
+		default:
+		}
+
+		isFront := s.waiters.Front() == elem
+		s.waiters.Remove(elem)
+		// 排在队首的请求被取消后，后面的小请求可能已经可以满足
+		if isFront && s.size > s.cur {
+			s.notifyWaiters()
+		}
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// TryAcquire 尝试获取 n 个单位，不阻塞，成功返回true；n 不为正时panic
+func (s *Semaphore) TryAcquire(n int64) bool {
+	checkWeight(n)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.size-s.cur >= n && s.waiters.Len() == 0 {
+		s.cur += n
+		return true
+	}
+	return false
+}
+
+// Release 释放 n 个单位
+// 释放超过已获取的数量或 n 不为正属于使用错误，会panic
+func (s *Semaphore) Release(n int64) {
+	checkWeight(n)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cur -= n
+	if s.cur < 0 {
+		panic("semaphore: released more than held")
+	}
+	s.notifyWaiters()
+}
+
+// checkWeight 检查获取或释放的数量
+// 负数会让 cur 反向变化，相当于悄悄扩大容量，所以直接panic
+func checkWeight(n int64) {
+	if n <= 0 {
+		panic(fmt.Sprintf("semaphore: n 必须为正, 实际 %d", n))
+	}
+}
+
+// notifyWaiters 按顺序唤醒能够满足的等待者，调用方必须持有mu
+func (s *Semaphore) notifyWaiters() {
+	for {
+		front := s.waiters.Front()
+		if front == nil {
+			return
+		}
+
+		w := front.Value.(waiter)
+		if s.size-s.cur < w.n {
+			// 队首请求还不能满足时停止，即使后面有更小的请求
+			// 这样大请求不会被源源不断的小请求饿死
+			return
+		}
+
+		s.cur += w.n
+		s.waiters.Remove(front)
+		close(w.ready)
+	}
+}
@@ -0,0 +1,250 @@
+package semaphore
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestAcquireRelease 测试基本的获取和释放
+func TestAcquireRelease(t *testing.T) {
+	s := New(5)
+	ctx := context.Background()
+
+	if err := s.Acquire(ctx, 3); err != nil {
+		t.Fatalf("Acquire(3) 返回错误: %v", err)
+	}
+	if !s.TryAcquire(2) {
+		t.Fatal("TryAcquire(2) 应该成功")
+	}
+	if s.TryAcquire(1) {
+		t.Fatal("容量已满时 TryAcquire(1) 应该失败")
+	}
+
+	s.Release(3)
+	if !s.TryAcquire(3) {
+		t.Fatal("释放后 TryAcquire(3) 应该成功")
+	}
+	s.Release(5)
+}
+
+// TestConcurrencyLimit 测试并发数不超过容量，与day1的goroutine池场景一致
+func TestConcurrencyLimit(t *testing.T) {
+	const poolSize = 5
+	const numJobs = 50
+
+	s := New(poolSize)
+	var running, maxRunning int64
+	var wg sync.WaitGroup
+
+	for i := 0; i < numJobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.Acquire(context.Background(), 1); err != nil {
+				t.Error(err)
+				return
+			}
+			defer s.Release(1)
+
+			cur := atomic.AddInt64(&running, 1)
+			for {
+				old := atomic.LoadInt64(&maxRunning)
+				if cur <= old || atomic.CompareAndSwapInt64(&maxRunning, old, cur) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning > poolSize {
+		t.Errorf("最大并发数 %d 超过容量 %d", maxRunning, poolSize)
+	}
+}
+
+// TestAcquireContextCancel 测试等待中的获取可以被取消
+func TestAcquireContextCancel(t *testing.T) {
+	s := New(1)
+	s.Acquire(context.Background(), 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := s.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Errorf("期望 DeadlineExceeded, 实际 %v", err)
+	}
+
+	// 取消的请求不应占用容量
+	s.Release(1)
+	if !s.TryAcquire(1) {
+		t.Error("取消后容量应该被完整释放")
+	}
+}
+
+// TestFIFONoStarvation 测试大请求不会被后来的小请求饿死
+func TestFIFONoStarvation(t *testing.T) {
+	s := New(10)
+	ctx := context.Background()
+	s.Acquire(ctx, 5)
+
+	bigDone := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 10) // 需要全部容量
+		close(bigDone)
+	}()
+	waitQueued(t, s, 1)
+
+	// 此时还有5个单位空闲，但大请求在排队，小请求不能插队
+	if s.TryAcquire(1) {
+		t.Fatal("有请求排队时 TryAcquire 不应插队")
+	}
+
+	smallDone := make(chan struct{})
+	go func() {
+		s.Acquire(ctx, 1)
+		close(smallDone)
+	}()
+	waitQueued(t, s, 2)
+
+	s.Release(5)
+	select {
+	case <-bigDone:
+	case <-time.After(time.Second):
+		t.Fatal("大请求没有获取成功")
+	}
+	select {
+	case <-smallDone:
+		t.Fatal("小请求不应在大请求释放前获取成功")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	s.Release(10)
+	<-smallDone
+	s.Release(1)
+}
+
+// TestCancelFrontWakesNext 测试队首请求取消后唤醒后面的请求
+func TestCancelFrontWakesNext(t *testing.T) {
+	s := New(3)
+	s.Acquire(context.Background(), 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bigErr := make(chan error)
+	go func() { bigErr <- s.Acquire(ctx, 3) }()
+	waitQueued(t, s, 1)
+
+	smallDone := make(chan struct{})
+	go func() {
+		s.Acquire(context.Background(), 1)
+		close(smallDone)
+	}()
+	waitQueued(t, s, 2)
+
+	cancel()
+	if err := <-bigErr; err != context.Canceled {
+		t.Errorf("期望 Canceled, 实际 %v", err)
+	}
+	select {
+	case <-smallDone:
+	case <-time.After(time.Second):
+		t.Fatal("队首取消后小请求应该被唤醒")
+	}
+}
+
+// TestReleaseTooMuchPanics 测试多释放会panic
+func TestReleaseTooMuchPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("释放超过持有数量应该panic")
+		}
+	}()
+	New(1).Release(1)
+}
+
+// TestNonPositiveWeightPanics 测试获取或释放的数量不为正时panic
+func TestNonPositiveWeightPanics(t *testing.T) {
+	calls := map[string]func(s *Semaphore){
+		"Acquire(0)":     func(s *Semaphore) { s.Acquire(context.Background(), 0) },
+		"TryAcquire(-1)": func(s *Semaphore) { s.TryAcquire(-1) },
+		"Release(-1)":    func(s *Semaphore) { s.Release(-1) },
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			s := New(1)
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s 应该panic", name)
+				}
+			}()
+			call(s)
+		})
+	}
+}
+
+// waitQueued 等待排队的请求数量达到n
+func waitQueued(t *testing.T, s *Semaphore, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		l := s.waiters.Len()
+		s.mu.Unlock()
+		if l == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待 %d 个请求排队超时", n)
+}
+
+// BenchmarkChannelSemaphore 基准测试：day1 goroutinePoolDemo 中的缓冲channel信号量
+func BenchmarkChannelSemaphore(b *testing.B) {
+	const poolSize = 5
+	semaphore := make(chan struct{}, poolSize)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			semaphore <- struct{}{}
+			<-semaphore
+		}
+	})
+}
+
+// BenchmarkSemaphore 基准测试：Semaphore 在相同场景下的开销
+func BenchmarkSemaphore(b *testing.B) {
+	const poolSize = 5
+	s := New(poolSize)
+	ctx := context.Background()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			s.Acquire(ctx, 1)
+			s.Release(1)
+		}
+	})
+}
+
+// BenchmarkUncontended 基准测试：无竞争时单次获取释放的开销
+func BenchmarkUncontended(b *testing.B) {
+	b.Run("Channel", func(b *testing.B) {
+		semaphore := make(chan struct{}, 1)
+		for i := 0; i < b.N; i++ {
+			semaphore <- struct{}{}
+			<-semaphore
+		}
+	})
+
+	b.Run("Semaphore", func(b *testing.B) {
+		s := New(1)
+		ctx := context.Background()
+		for i := 0; i < b.N; i++ {
+			s.Acquire(ctx, 1)
+			s.Release(1)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// checkGoroutineLeak 等待goroutine数量回落到基线，超时则报告泄漏
+func checkGoroutineLeak(t *testing.T, baseline int) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if runtime.NumGoroutine() <= baseline {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	buf := make([]byte, 1<<16)
+	n := runtime.Stack(buf, true)
+	t.Errorf("goroutine泄漏: 基线 %d, 当前 %d\n%s", baseline, runtime.NumGoroutine(), buf[:n])
+}
+
+// TestCancelStopsWorkers 测试取消后worker退出且未执行的任务被记录
+func TestCancelStopsWorkers(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan int, 10)
+
+	p := New(ctx, 2, func(ctx context.Context, n int) (int, error) {
+		started <- n
+		<-ctx.Done() // 模拟一直运行到被取消的任务
+		return n, ctx.Err()
+	}, WithQueueSize(10), WithResultSize(10))
+
+	for i := 1; i <= 10; i++ {
+		if err := p.Submit(i); err != nil {
+			t.Fatalf("Submit(%d) 返回错误: %v", i, err)
+		}
+	}
+
+	// 等两个worker都开始工作后再取消
+	<-started
+	<-started
+	cancel()
+	p.Wait()
+
+	if err := p.Submit(11); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("取消后Submit期望 ErrPoolClosed, 实际 %v", err)
+	}
+
+	executed := len(started)
+	for range p.Results() {
+	}
+	skipped := p.Skipped()
+	if len(skipped)+executed+2 != 10 {
+		t.Errorf("执行 %d + 跳过 %d 不等于提交的 10 个任务", executed+2, len(skipped))
+	}
+	if len(skipped) < 8-executed {
+		t.Errorf("期望至少跳过 %d 个任务, 实际 %v", 8-executed, skipped)
+	}
+
+	checkGoroutineLeak(t, baseline)
+}
+
+// TestCancelWithAbandonedResults 测试无人读取结果时取消不会泄漏goroutine
+func TestCancelWithAbandonedResults(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, 4, func(_ context.Context, n int) (int, error) { return n, nil })
+
+	go func() {
+		for i := 0; ; i++ {
+			if err := p.Submit(i); err != nil {
+				return
+			}
+		}
+	}()
+
+	// 结果Channel无人读取，worker都会阻塞在发送上
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		p.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("取消后 Wait 没有返回")
+	}
+
+	checkGoroutineLeak(t, baseline)
+}
+
+// TestSubmitBlockedUnblocksOnCancel 测试阻塞中的Submit在取消后返回
+func TestSubmitBlockedUnblocksOnCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	block := make(chan struct{})
+	p := New(ctx, 1, func(_ context.Context, n int) (int, error) {
+		<-block
+		return n, nil
+	})
+
+	p.Submit(1) // 被worker领取后阻塞
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- p.Submit(2) // 队列非缓冲，这里会阻塞
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("期望 context.Canceled, 实际 %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("取消后 Submit 仍然阻塞")
+	}
+
+	close(block)
+	p.Wait()
+	checkGoroutineLeak(t, baseline)
+}
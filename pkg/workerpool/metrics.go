@@ -0,0 +1,134 @@
+package workerpool
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StatsSource 是可以提供统计快照的对象，Pool 和 PriorityPool 都实现了它
+type StatsSource interface {
+	Stats() Stats
+}
+
+// Exporter 以Prometheus文本格式导出一个或多个工作池的统计信息
+//
+//	exp := workerpool.NewExporter()
+//	exp.Register("images", pool)
+//	http.Handle("/metrics", exp)
+//	go http.ListenAndServe("127.0.0.1:9100", nil)
+type Exporter struct {
+	mu      sync.RWMutex
+	sources map[string]StatsSource
+}
+
+// NewExporter 创建一个空的导出器
+func NewExporter() *Exporter {
+	return &Exporter{sources: make(map[string]StatsSource)}
+}
+
+// Register 以name为标签注册一个工作池，重复注册会覆盖
+func (e *Exporter) Register(name string, src StatsSource) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.sources[name] = src
+}
+
+// Unregister 取消注册
+func (e *Exporter) Unregister(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.sources, name)
+}
+
+// ServeHTTP 实现 http.Handler
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteTo(w)
+}
+
+// WriteTo 把所有工作池的指标以Prometheus文本格式写入w
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.RLock()
+	names := make([]string, 0, len(e.sources))
+	for name := range e.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	snapshots := make([]Stats, len(names))
+	for i, name := range names {
+		snapshots[i] = e.sources[name].Stats()
+	}
+	e.mu.RUnlock()
+
+	// 之后names只用作标签值
+	for i := range names {
+		names[i] = labelEscaper.Replace(names[i])
+	}
+
+	cw := &countingWriter{w: w}
+
+	writeMetric := func(metric, help, typ string, value func(Stats) string) {
+		fmt.Fprintf(cw, "# HELP %s %s\n# TYPE %s %s\n", metric, help, metric, typ)
+		for i, name := range names {
+			fmt.Fprintf(cw, "%s{pool=\"%s\"} %s\n", metric, name, value(snapshots[i]))
+		}
+	}
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+
+	writeMetric("workerpool_jobs_submitted_total", "Total number of submitted jobs.", "counter",
+		func(s Stats) string { return itoa(s.Submitted) })
+	writeMetric("workerpool_jobs_completed_total", "Total number of jobs that succeeded.", "counter",
+		func(s Stats) string { return itoa(s.Completed) })
+	writeMetric("workerpool_jobs_failed_total", "Total number of jobs that failed after all retries.", "counter",
+		func(s Stats) string { return itoa(s.Failed) })
+	writeMetric("workerpool_jobs_in_flight", "Number of jobs currently running.", "gauge",
+		func(s Stats) string { return itoa(s.InFlight) })
+	writeMetric("workerpool_queue_length", "Number of jobs waiting in the queue.", "gauge",
+		func(s Stats) string { return strconv.Itoa(s.QueueLen) })
+	writeMetric("workerpool_workers", "Number of workers.", "gauge",
+		func(s Stats) string { return strconv.Itoa(s.Workers) })
+
+	const hist = "workerpool_job_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s Job execution time including retries.\n# TYPE %s histogram\n", hist, hist)
+	for i, name := range names {
+		h := snapshots[i].Duration
+		var cumulative uint64
+		for j, bound := range h.Bounds {
+			cumulative += h.Counts[j]
+			fmt.Fprintf(cw, "%s_bucket{pool=\"%s\",le=\"%s\"} %d\n", hist, name,
+				strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(cw, "%s_bucket{pool=\"%s\",le=\"+Inf\"} %d\n", hist, name, h.Count)
+		fmt.Fprintf(cw, "%s_sum{pool=\"%s\"} %s\n", hist, name,
+			strconv.FormatFloat(h.Sum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(cw, "%s_count{pool=\"%s\"} %d\n", hist, name, h.Count)
+	}
+
+	return cw.n, cw.err
+}
+
+// labelEscaper 按Prometheus文本格式转义标签值：只转义反斜杠、双引号和换行，
+// 其他字符（包括非ASCII字符）原样输出
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// countingWriter 记录写入的字节数和第一个错误
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
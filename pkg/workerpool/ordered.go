@@ -0,0 +1,157 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+)
+
+// WithOrdered 开启有序模式：Results 按 Submit 的顺序交付结果
+//
+// 每个任务在提交时获得一个序号，worker完成后把结果放入重排缓冲区，
+// 排序goroutine只在下一个序号到达时才向外发送。
+// window 限制缓冲区大小：已提交但尚未交付的任务达到 window 个时，
+// Submit 会阻塞，直到最早的结果被取走。window 小于1时按1处理。
+//
+// 多个goroutine并发调用 Submit 时，它们之间的顺序由获得序号的先后决定。
+func WithOrdered(window int) Option {
+	return func(c *config) {
+		if window < 1 {
+			window = 1
+		}
+		c.window = window
+	}
+}
+
+// sequenced 是带序号的结果
+type sequenced[J, R any] struct {
+	seq    uint64
+	result Result[J, R]
+	skip   bool // 序号没有对应的任务，直接跳过
+}
+
+// reorderer 把乱序完成的结果按序号重新排好
+type reorderer[J, R any] struct {
+	ctx  context.Context
+	out  chan<- Result[J, R]
+	in   chan sequenced[J, R]
+	slot chan struct{} // 窗口信号量，容量即窗口大小
+	done chan struct{} // 排序goroutine退出时关闭
+
+	mu    sync.Mutex
+	next  uint64   // 下一个要分配的序号
+	spare []uint64 // 被归还的序号，仍占用窗口位置
+}
+
+func newReorderer[J, R any](ctx context.Context, window int, out chan<- Result[J, R]) *reorderer[J, R] {
+	ro := &reorderer[J, R]{
+		ctx:  ctx,
+		out:  out,
+		in:   make(chan sequenced[J, R], window),
+		slot: make(chan struct{}, window),
+		done: make(chan struct{}),
+	}
+	go ro.run()
+	return ro
+}
+
+// reserve 占用一个窗口位置并分配序号，有归还的序号时优先复用
+// preempt 收到信号时放弃等待并返回 errPreempted，closing 关闭时返回 ErrPoolClosed
+func (ro *reorderer[J, R]) reserve(preempt, closing <-chan struct{}) (uint64, error) {
+	ro.mu.Lock()
+	if len(ro.spare) > 0 {
+		seq := ro.spare[0]
+		ro.spare = ro.spare[1:]
+		ro.mu.Unlock()
+		return seq, nil
+	}
+	ro.mu.Unlock()
+
+	select {
+	case ro.slot <- struct{}{}:
+	case <-preempt:
+		return 0, errPreempted
+	case <-closing:
+		return 0, ErrPoolClosed
+	case <-ro.ctx.Done():
+		return 0, ro.ctx.Err()
+	}
+
+	ro.mu.Lock()
+	seq := ro.next
+	ro.next++
+	ro.mu.Unlock()
+	return seq, nil
+}
+
+// unreserve 归还一个已分配但没有用上的序号，它会被下一次 reserve 复用，
+// 否则排序goroutine会一直等这个序号的结果
+func (ro *reorderer[J, R]) unreserve(seq uint64) {
+	ro.mu.Lock()
+	ro.spare = append(ro.spare, seq)
+	ro.mu.Unlock()
+}
+
+// skip 放弃一个已分配的序号，排序goroutine到达它时直接跳过
+// 与put一样占用窗口位置对应的缓冲，不会阻塞
+func (ro *reorderer[J, R]) skip(seq uint64) {
+	select {
+	case ro.in <- sequenced[J, R]{seq: seq, skip: true}:
+	case <-ro.ctx.Done():
+	}
+}
+
+// put 把完成的结果交给排序goroutine，context取消时返回false
+func (ro *reorderer[J, R]) put(seq uint64, result Result[J, R]) bool {
+	// in的容量等于窗口大小，正常情况下不会阻塞
+	select {
+	case ro.in <- sequenced[J, R]{seq: seq, result: result}:
+		return true
+	case <-ro.ctx.Done():
+		return false
+	}
+}
+
+// close 在所有worker退出后调用，等待排序goroutine发完剩余结果并关闭out
+func (ro *reorderer[J, R]) close() {
+	close(ro.in)
+	<-ro.done
+}
+
+// run 是排序goroutine
+func (ro *reorderer[J, R]) run() {
+	defer close(ro.done)
+	defer close(ro.out)
+
+	var next uint64
+	pending := make(map[uint64]sequenced[J, R])
+
+	for {
+		select {
+		case <-ro.ctx.Done():
+			return
+		case s, ok := <-ro.in:
+			if !ok {
+				return
+			}
+			pending[s.seq] = s
+
+			// 把从next开始连续到达的结果依次发出
+			for {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				if !r.skip {
+					select {
+					case ro.out <- r.result:
+					case <-ro.ctx.Done():
+						return
+					}
+				}
+				delete(pending, next)
+				next++
+				<-ro.slot
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package workerpool
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestOrderedResults 测试有序模式下结果按提交顺序交付
+// 场景与 parallelPipeline 相同：4个worker并行处理，每个任务耗时随机
+func TestOrderedResults(t *testing.T) {
+	const numJobs = 200
+
+	p := New(context.Background(), 4, func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		return n * 100, nil
+	}, WithOrdered(16))
+
+	go func() {
+		for i := 1; i <= numJobs; i++ {
+			p.Submit(i)
+		}
+		p.Close()
+	}()
+
+	want := 1
+	for r := range p.Results() {
+		if r.Job != want || r.Value != want*100 {
+			t.Fatalf("第 %d 个结果是 Job %d (值 %d)", want, r.Job, r.Value)
+		}
+		want++
+	}
+	if want != numJobs+1 {
+		t.Errorf("期望 %d 个结果, 实际 %d", numJobs, want-1)
+	}
+}
+
+// TestOrderedWindowBounds 测试窗口限制了已提交但未交付的任务数量
+func TestOrderedWindowBounds(t *testing.T) {
+	const window = 3
+
+	release := make(chan struct{})
+	var started int64
+	p := New(context.Background(), 8, func(_ context.Context, n int) (int, error) {
+		atomic.AddInt64(&started, 1)
+		if n == 0 {
+			<-release // 第一个任务很慢，后面的结果都要在缓冲区等它
+		}
+		return n, nil
+	}, WithOrdered(window), WithQueueSize(10))
+
+	submitted := make(chan int, 10)
+	go func() {
+		for i := 0; i < 10; i++ {
+			p.Submit(i)
+			submitted <- i
+		}
+		p.Close()
+	}()
+
+	time.Sleep(30 * time.Millisecond)
+	if n := len(submitted); n != window {
+		t.Errorf("窗口为 %d 时成功提交了 %d 个任务", window, n)
+	}
+
+	close(release)
+	count := 0
+	for r := range p.Results() {
+		if r.Job != count {
+			t.Fatalf("第 %d 个结果是 Job %d", count, r.Job)
+		}
+		count++
+	}
+	if count != 10 {
+		t.Errorf("期望10个结果, 实际 %d", count)
+	}
+}
+
+// TestOrderedCancel 测试有序模式下取消不会泄漏goroutine
+func TestOrderedCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, 4, func(ctx context.Context, n int) (int, error) {
+		if n == 0 {
+			<-ctx.Done()
+		}
+		return n, nil
+	}, WithOrdered(8))
+
+	go func() {
+		for i := 0; ; i++ {
+			if p.Submit(i) != nil {
+				return
+			}
+		}
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	p.Wait()
+
+	checkGoroutineLeak(t, baseline)
+}
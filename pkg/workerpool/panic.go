@@ -0,0 +1,39 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// PanicError 表示任务函数发生了panic
+//
+// worker会recover这个panic，把它作为该任务的 Result.Err 交付，
+// 然后继续处理下一个任务，工作池的容量不受影响。
+// Error 只包含panic的值，需要调用栈时从 Stack 字段读取。
+type PanicError struct {
+	Value any    // recover() 得到的值
+	Stack []byte // 发生panic时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("workerpool: job panicked: %v", e.Value)
+}
+
+// Unwrap 当panic的值本身是error时返回它，便于 errors.Is/As 判断
+func (e *PanicError) Unwrap() error {
+	if err, ok := e.Value.(error); ok {
+		return err
+	}
+	return nil
+}
+
+// safeCall 调用fn并把panic转换为 *PanicError
+func safeCall[J, R any](ctx context.Context, fn func(context.Context, J) (R, error), job J) (value R, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return fn(ctx, job)
+}
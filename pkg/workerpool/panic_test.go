@@ -0,0 +1,101 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestPanicBecomesResult 测试panic被转换为该任务的 PanicError 结果
+func TestPanicBecomesResult(t *testing.T) {
+	p := New(context.Background(), 2, func(_ context.Context, n int) (int, error) {
+		if n%3 == 0 {
+			panic("处理失败")
+		}
+		return n, nil
+	}, WithQueueSize(30), WithResultSize(30))
+
+	for i := 1; i <= 30; i++ {
+		p.Submit(i)
+	}
+	p.Close()
+	p.Wait()
+
+	panics := 0
+	for r := range p.Results() {
+		var pe *PanicError
+		if errors.As(r.Err, &pe) {
+			panics++
+			if r.Job%3 != 0 {
+				t.Errorf("Job %d 不应该panic", r.Job)
+			}
+			if pe.Value != "处理失败" {
+				t.Errorf("PanicError.Value = %v", pe.Value)
+			}
+			if !strings.Contains(string(pe.Stack), "panic_test.go") {
+				t.Errorf("调用栈中没有发生panic的位置:\n%s", pe.Stack)
+			}
+			if msg := pe.Error(); msg != "workerpool: job panicked: 处理失败" {
+				t.Errorf("Error() = %q, 不应包含调用栈", msg)
+			}
+		} else if r.Err != nil {
+			t.Errorf("Job %d 意外错误: %v", r.Job, r.Err)
+		}
+	}
+	if panics != 10 {
+		t.Errorf("期望10个panic结果, 实际 %d", panics)
+	}
+	if s := p.Stats(); s.Failed != 10 || s.Completed != 20 {
+		t.Errorf("Failed/Completed = %d/%d, 期望 10/20", s.Failed, s.Completed)
+	}
+}
+
+// TestPanicKeepsCapacity 测试所有worker都panic过后仍能继续处理任务
+func TestPanicKeepsCapacity(t *testing.T) {
+	const workers = 3
+	p := New(context.Background(), workers, func(_ context.Context, n int) (int, error) {
+		if n < 0 {
+			panic(n)
+		}
+		return n, nil
+	}, WithQueueSize(10), WithResultSize(10))
+
+	for i := 1; i <= workers*2; i++ {
+		p.Submit(-i)
+	}
+	for i := 0; i < workers*2; i++ {
+		<-p.Results()
+	}
+
+	if p.Size() != workers {
+		t.Errorf("panic后worker数量 %d, 期望 %d", p.Size(), workers)
+	}
+
+	p.Submit(42)
+	if r := <-p.Results(); r.Err != nil || r.Value != 42 {
+		t.Errorf("panic后的任务结果 = %v, %v", r.Value, r.Err)
+	}
+	p.Close()
+	p.Wait()
+}
+
+// TestPanicNotRetried 测试panic不会触发重试，且可以用errors.Is找到原始错误
+func TestPanicNotRetried(t *testing.T) {
+	errBoom := errors.New("boom")
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) {
+		panic(errBoom)
+	}, WithRetry(RetryPolicy{MaxAttempts: 5}))
+
+	p.Submit(1)
+	p.Close()
+
+	r := <-p.Results()
+	if r.Attempts != 1 {
+		t.Errorf("panic后执行次数 %d, 期望 1", r.Attempts)
+	}
+	if !errors.Is(r.Err, errBoom) {
+		t.Errorf("errors.Is(r.Err, errBoom) = false, r.Err = %v", r.Err)
+	}
+	p.Wait()
+}
@@ -0,0 +1,393 @@
+// Package workerpool 提供一个可复用的泛型工作池。
+//
+// 它是 week1/day2 练习5中 workerPool 的通用版本：
+// 固定数量的worker从任务队列读取任务，处理后把结果写入结果Channel，
+// 所有worker退出后关闭结果Channel。
+//
+// 工作池绑定一个 context.Context：context取消后worker不再领取新任务，
+// 队列中尚未执行的任务会被记录下来，可通过 Skipped 查看。
+//
+// 任务函数返回 (R, error)，失败的任务可以按 RetryPolicy 重试，
+// 每个任务最终产生一个 Result，记录执行次数和最后一次的错误。
+//
+// worker数量可以在运行时通过 Resize 调整，也可以交给 Autoscale 根据
+// 队列深度和任务延迟自动调整。
+//
+// Stats 返回计数器和执行耗时分位数的快照，Exporter 可以把它们
+// 以Prometheus文本格式通过HTTP暴露出去。
+//
+// 任务函数中的panic会被worker捕获并转换为 *PanicError，
+// 一个任务panic不会导致整个进程退出，也不会减少worker数量。
+//
+// 默认情况下结果按完成顺序交付；WithOrdered 开启有序模式后，
+// 结果按提交顺序交付，适合写文件、写数据库这类需要保持输入顺序的场景。
+//
+// Shutdown 实现练习6中的优雅关闭：停止接收新任务，在期限内处理完队列，
+// 超时后取消正在执行的任务，并报告完成、丢弃和放弃的任务。
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrPoolClosed 在工作池关闭后继续提交任务时返回
+var ErrPoolClosed = errors.New("workerpool: pool is closed")
+
+// errPreempted 表示提交在交给worker之前被放弃，只在包内使用
+var errPreempted = errors.New("workerpool: submit preempted")
+
+// Option 配置工作池的可选参数
+type Option func(*config)
+
+type config struct {
+	queueSize  int
+	resultSize int
+	retry      RetryPolicy
+	aging      time.Duration
+	window     int
+}
+
+// WithQueueSize 设置任务队列的缓冲区大小（默认0，即非缓冲）
+func WithQueueSize(n int) Option {
+	return func(c *config) {
+		if n >= 0 {
+			c.queueSize = n
+		}
+	}
+}
+
+// WithResultSize 设置结果Channel的缓冲区大小（默认0，即非缓冲）
+func WithResultSize(n int) Option {
+	return func(c *config) {
+		if n >= 0 {
+			c.resultSize = n
+		}
+	}
+}
+
+// Result 是一个任务的最终结果
+type Result[J, R any] struct {
+	Job      J     // 原始任务
+	Value    R     // 任务返回值，Err不为nil时通常为零值
+	Err      error // 最后一次执行的错误
+	Attempts int   // 实际执行次数
+}
+
+// Pool 是一个泛型工作池，J 为任务类型，R 为结果类型
+//
+// 使用约定与练习5一致：
+//  1. 调用方通过 Submit 提交任务
+//  2. 调用方必须持续读取 Results，否则worker会阻塞在发送结果上
+//  3. 调用 Close 表示不再提交任务，worker处理完剩余任务后退出
+//  4. 所有worker退出后 Results 会被关闭
+//  5. context取消后worker尽快退出，即使 Results 无人读取也不会泄漏goroutine
+type Pool[J, R any] struct {
+	ctx     context.Context
+	cancel  context.CancelFunc // Shutdown 超时后用来取消正在执行的任务
+	fn      func(context.Context, J) (R, error)
+	retry   RetryPolicy
+	jobs    chan task[J]
+	results chan Result[J, R]
+
+	mu        sync.RWMutex
+	closed    bool
+	skipped   []J
+	abandoned []J
+
+	// Submit 阻塞在jobs上时不持有mu，Close 关闭closing唤醒它们，
+	// 等senders归零后才关闭jobs，避免向已关闭的channel发送
+	closing chan struct{}
+	senders sync.WaitGroup
+	blocked atomic.Int64 // 正在等待worker或队列空位的 Submit 数量
+
+	// sizeMu 保护worker列表，和mu分开是因为扩容时不应等待其他锁
+	sizeMu  sync.Mutex
+	stops   []chan struct{} // 每个worker一个停止信号
+	exiting bool            // 已有worker因关闭或取消而退出，不能再扩容
+
+	latency  latencyTracker
+	counters counters
+
+	// 有序模式，见 WithOrdered
+	ordered *reorderer[J, R]
+
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+// task 是队列中的任务，附带提交时间用于统计延迟
+type task[J any] struct {
+	job       J
+	submitted time.Time
+	seq       uint64 // 有序模式下的提交序号
+}
+
+// New 创建并启动一个包含 workers 个worker的工作池
+// workers 小于1时按1处理；ctx 取消后工作池停止领取新任务
+// fn 收到的ctx即为工作池的ctx，长时间运行的任务应当检查它
+func New[J, R any](ctx context.Context, workers int, fn func(context.Context, J) (R, error), opts ...Option) *Pool[J, R] {
+	if workers < 1 {
+		workers = 1
+	}
+
+	cfg := config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p := &Pool[J, R]{
+		ctx:     ctx,
+		cancel:  cancel,
+		fn:      fn,
+		retry:   cfg.retry,
+		jobs:    make(chan task[J], cfg.queueSize),
+		results: make(chan Result[J, R], cfg.resultSize),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if cfg.window > 0 {
+		p.ordered = newReorderer(ctx, cfg.window, p.results)
+	}
+
+	p.sizeMu.Lock()
+	for i := 0; i < workers; i++ {
+		p.startWorker()
+	}
+	p.sizeMu.Unlock()
+
+	// 等待所有worker完成，然后关闭results channel
+	go func() {
+		p.wg.Wait()
+		if p.ordered != nil {
+			p.ordered.close() // 排序goroutine发完剩余结果后关闭results
+		} else {
+			close(p.results)
+		}
+		p.collectSkipped()
+		p.cancel() // 释放派生context的资源
+		close(p.done)
+	}()
+
+	return p
+}
+
+// startWorker 启动一个新的worker，调用方必须持有sizeMu
+func (p *Pool[J, R]) startWorker() {
+	stop := make(chan struct{})
+	p.stops = append(p.stops, stop)
+	p.wg.Add(1)
+	go p.worker(stop)
+}
+
+// worker 从jobs channel读取任务，直到channel关闭、context取消或收到停止信号
+func (p *Pool[J, R]) worker(stop <-chan struct{}) {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-stop:
+			// 缩容：当前任务已经处理完，直接退出
+			return
+		case <-p.ctx.Done():
+			p.markExiting()
+			return
+		case t, ok := <-p.jobs:
+			if !ok {
+				p.markExiting()
+				return
+			}
+			// select在多个case就绪时随机选择，这里再检查一次
+			// 保证取消后领到的任务不会被执行
+			if p.ctx.Err() != nil {
+				p.skip(t.job)
+				p.markExiting()
+				return
+			}
+
+			p.counters.inFlight.Add(1)
+			start := time.Now()
+			value, attempts, err := runWithRetry(p.ctx, p.retry, p.fn, t.job)
+			p.counters.duration.observe(time.Since(start))
+			p.counters.inFlight.Add(-1)
+
+			// 执行期间被取消，结果不可信，也没有人会再读取它
+			if p.ctx.Err() != nil {
+				p.abandon(t.job)
+				p.markExiting()
+				return
+			}
+
+			if err != nil {
+				p.counters.failed.Add(1)
+			} else {
+				p.counters.completed.Add(1)
+			}
+			p.latency.observe(time.Since(t.submitted))
+			result := Result[J, R]{Job: t.job, Value: value, Err: err, Attempts: attempts}
+
+			if !p.emit(t.seq, result) {
+				// 没有人再读取结果，丢弃它以免worker永久阻塞
+				p.markExiting()
+				return
+			}
+		}
+	}
+}
+
+// emit 交付一个结果，context取消时返回false
+func (p *Pool[J, R]) emit(seq uint64, result Result[J, R]) bool {
+	if p.ordered != nil {
+		return p.ordered.put(seq, result)
+	}
+
+	select {
+	case p.results <- result:
+		return true
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+// markExiting 标记工作池进入退出阶段，此后 Resize 不再启动新worker
+func (p *Pool[J, R]) markExiting() {
+	p.sizeMu.Lock()
+	p.exiting = true
+	p.sizeMu.Unlock()
+}
+
+// skip 记录一个因取消而未执行的任务
+func (p *Pool[J, R]) skip(job J) {
+	p.mu.Lock()
+	p.skipped = append(p.skipped, job)
+	p.mu.Unlock()
+}
+
+// abandon 记录一个执行到一半被取消的任务
+func (p *Pool[J, R]) abandon(job J) {
+	p.mu.Lock()
+	p.abandoned = append(p.abandoned, job)
+	p.mu.Unlock()
+}
+
+// collectSkipped 在所有worker退出后关闭任务队列，并把队列中剩余的任务记为跳过
+func (p *Pool[J, R]) collectSkipped() {
+	p.Close()
+
+	var rest []J
+	for t := range p.jobs {
+		rest = append(rest, t.job)
+	}
+
+	p.mu.Lock()
+	p.skipped = append(p.skipped, rest...)
+	p.mu.Unlock()
+}
+
+// Submit 提交一个任务，队列已满时阻塞
+// 工作池关闭后（包括阻塞期间被 Close）返回 ErrPoolClosed，context取消后返回 ctx.Err()
+func (p *Pool[J, R]) Submit(job J) error {
+	return p.submit(job, nil)
+}
+
+// submit 与 Submit 相同，但在任务被worker取走之前 preempt 收到信号时
+// 放弃提交并返回 errPreempted，有序模式下已分配的序号会留给下一次提交
+func (p *Pool[J, R]) submit(job J, preempt <-chan struct{}) error {
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPoolClosed
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.mu.RUnlock()
+		return err
+	}
+	p.senders.Add(1)
+	p.mu.RUnlock()
+	defer p.senders.Done()
+
+	t := task[J]{job: job}
+	if p.ordered != nil {
+		// 有序模式下先占用窗口，窗口满时等待最早的结果被取走
+		seq, err := p.ordered.reserve(preempt, p.closing)
+		if err != nil {
+			return err
+		}
+		t.seq = seq
+	}
+
+	// 先计数再入队，保证快照中 Submitted 不会小于 Completed+Failed
+	p.counters.submitted.Add(1)
+	t.submitted = time.Now()
+	select {
+	case p.jobs <- t:
+		return nil
+	default:
+	}
+
+	// 队列已满（非缓冲队列即没有空闲worker），阻塞期间计入 QueueLen
+	p.blocked.Add(1)
+	defer p.blocked.Add(-1)
+	select {
+	case p.jobs <- t:
+		return nil
+	case <-preempt:
+		p.counters.submitted.Add(-1)
+		if p.ordered != nil {
+			p.ordered.unreserve(t.seq)
+		}
+		return errPreempted
+	case <-p.closing:
+		p.counters.submitted.Add(-1)
+		if p.ordered != nil {
+			p.ordered.skip(t.seq)
+		}
+		return ErrPoolClosed
+	case <-p.ctx.Done():
+		p.counters.submitted.Add(-1)
+		return p.ctx.Err()
+	}
+}
+
+// Results 返回结果Channel，所有worker退出后该Channel被关闭
+func (p *Pool[J, R]) Results() <-chan Result[J, R] {
+	return p.results
+}
+
+// Close 停止接收新任务，已进入队列的任务仍会被处理，
+// 阻塞中的 Submit 返回 ErrPoolClosed。多次调用是安全的
+func (p *Pool[J, R]) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.closing)
+	p.mu.Unlock()
+
+	// 阻塞的Submit被closing唤醒后很快返回，之后才能关闭jobs
+	p.senders.Wait()
+	close(p.jobs)
+}
+
+// Wait 阻塞直到所有worker退出且 Results 已关闭
+// 调用方需要在另一个goroutine中读取 Results，否则可能死锁
+func (p *Pool[J, R]) Wait() {
+	<-p.done
+}
+
+// Skipped 返回因context取消而没有执行的任务
+// 只有在 Wait 返回之后结果才完整
+func (p *Pool[J, R]) Skipped() []J {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	skipped := make([]J, len(p.skipped))
+	copy(skipped, p.skipped)
+	return skipped
+}
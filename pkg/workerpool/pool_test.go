@@ -0,0 +1,134 @@
+package workerpool
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// TestPoolEveryJobOneResult 测试每个任务恰好产生一个结果
+func TestPoolEveryJobOneResult(t *testing.T) {
+	const numJobs = 200
+
+	p := New(context.Background(), 5, func(_ context.Context, n int) (int, error) { return n * 2, nil }, WithQueueSize(10))
+
+	go func() {
+		for i := 1; i <= numJobs; i++ {
+			if err := p.Submit(i); err != nil {
+				t.Errorf("Submit(%d) 返回错误: %v", i, err)
+			}
+		}
+		p.Close()
+	}()
+
+	seen := make(map[int]int)
+	for r := range p.Results() {
+		seen[r.Value]++
+	}
+	p.Wait()
+
+	if len(seen) != numJobs {
+		t.Fatalf("期望 %d 个不同结果, 实际 %d", numJobs, len(seen))
+	}
+	for i := 1; i <= numJobs; i++ {
+		if seen[i*2] != 1 {
+			t.Errorf("任务 %d 的结果出现 %d 次, 期望1次", i, seen[i*2])
+		}
+	}
+}
+
+// TestPoolConcurrencyLimit 测试同时运行的worker数量不超过设定值
+func TestPoolConcurrencyLimit(t *testing.T) {
+	const workers = 3
+
+	var running, maxRunning int64
+	var mu sync.Mutex
+	gate := make(chan struct{})
+
+	p := New(context.Background(), workers, func(_ context.Context, n int) (int, error) {
+		cur := atomic.AddInt64(&running, 1)
+		mu.Lock()
+		if cur > maxRunning {
+			maxRunning = cur
+		}
+		mu.Unlock()
+		<-gate
+		atomic.AddInt64(&running, -1)
+		return n, nil
+	})
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			p.Submit(i)
+		}
+		p.Close()
+	}()
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			gate <- struct{}{}
+		}
+	}()
+
+	count := 0
+	for range p.Results() {
+		count++
+	}
+
+	if count != 20 {
+		t.Errorf("期望20个结果, 实际 %d", count)
+	}
+	if maxRunning > workers {
+		t.Errorf("最大并发数 %d 超过限制 %d", maxRunning, workers)
+	}
+}
+
+// TestPoolSubmitAfterClose 测试关闭后提交返回 ErrPoolClosed
+func TestPoolSubmitAfterClose(t *testing.T) {
+	p := New(context.Background(), 2, func(_ context.Context, s string) (string, error) { return s, nil })
+	p.Close()
+	p.Close() // 重复关闭不应panic
+
+	if err := p.Submit("x"); err != ErrPoolClosed {
+		t.Errorf("期望 ErrPoolClosed, 实际 %v", err)
+	}
+
+	for range p.Results() {
+	}
+	p.Wait()
+}
+
+// TestPoolStructTypes 测试使用练习5中的Job/Result结构
+func TestPoolStructTypes(t *testing.T) {
+	type Job struct {
+		ID   int
+		Data string
+	}
+	type Result struct {
+		JobID int
+		Value string
+	}
+
+	p := New(context.Background(), 4, func(_ context.Context, j Job) (Result, error) {
+		return Result{JobID: j.ID, Value: "处理完成-" + j.Data}, nil
+	}, WithQueueSize(20), WithResultSize(20))
+
+	for i := 1; i <= 20; i++ {
+		p.Submit(Job{ID: i, Data: fmt.Sprintf("工作%d", i)})
+	}
+	p.Close()
+	p.Wait()
+
+	ids := make(map[int]bool)
+	for r := range p.Results() {
+		if r.Value.Value != fmt.Sprintf("处理完成-工作%d", r.Job.ID) {
+			t.Errorf("Job %d 结果错误: %s", r.Job.ID, r.Value.Value)
+		}
+		ids[r.Value.JobID] = true
+	}
+	if len(ids) != 20 {
+		t.Errorf("期望20个结果, 实际 %d", len(ids))
+	}
+}
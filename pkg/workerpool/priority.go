@@ -0,0 +1,246 @@
+package workerpool
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// PriorityPool 是带优先级的工作池前端
+//
+// 任务先进入一个优先级队列，由调度goroutine按优先级逐个交给内部的 Pool。
+// 内部 Pool 使用非缓冲的任务队列，只有worker空闲时才会取走任务；
+// 调度goroutine等待worker期间如果来了更高优先级的任务，
+// 会把手上的任务放回队列，改为提交新任务。
+//
+// 开启老化(WithAging)后，任务每等待一个老化周期，有效优先级加1，
+// 低优先级任务不会被源源不断的高优先级任务永远饿死。
+type PriorityPool[J, R any] struct {
+	pool  *Pool[J, R]
+	ctx   context.Context
+	aging time.Duration
+	start time.Time
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   priorityQueue[J]
+	seq     uint64
+	closed  bool
+	skipped []J
+
+	held    *priorityItem[J] // 调度goroutine正在等待worker的任务
+	preempt chan struct{}    // 有比held更优先的任务到达，容量为1
+
+	done chan struct{}
+}
+
+// WithAging 设置优先级工作池的老化周期：任务每等待 d，有效优先级加1
+// 默认不老化；对普通 Pool 不起作用
+func WithAging(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.aging = d
+		}
+	}
+}
+
+// NewPriority 创建一个带优先级的工作池，参数含义与 New 相同
+// 内部 Pool 的任务队列固定为非缓冲，WithQueueSize 不起作用
+func NewPriority[J, R any](ctx context.Context, workers int, fn func(context.Context, J) (R, error), opts ...Option) *PriorityPool[J, R] {
+	cfg := config{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// 新建切片，不写入调用方的opts底层数组
+	opts = append(opts[:len(opts):len(opts)], WithQueueSize(0))
+	pp := &PriorityPool[J, R]{
+		pool:    New(ctx, workers, fn, opts...),
+		ctx:     ctx,
+		aging:   cfg.aging,
+		start:   time.Now(),
+		preempt: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	pp.cond = sync.NewCond(&pp.mu)
+
+	go pp.dispatch()
+
+	// context取消时唤醒调度goroutine
+	go func() {
+		select {
+		case <-ctx.Done():
+			pp.mu.Lock()
+			pp.cond.Broadcast()
+			pp.mu.Unlock()
+		case <-pp.done:
+		}
+	}()
+
+	return pp
+}
+
+// Submit 以默认优先级0提交任务
+func (pp *PriorityPool[J, R]) Submit(job J) error {
+	return pp.SubmitPriority(job, 0)
+}
+
+// SubmitPriority 以指定优先级提交任务，数值越大越优先
+// 任务进入优先级队列，不会阻塞
+func (pp *PriorityPool[J, R]) SubmitPriority(job J, priority int) error {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+
+	if pp.closed {
+		return ErrPoolClosed
+	}
+	if err := pp.ctx.Err(); err != nil {
+		return err
+	}
+
+	pp.seq++
+	item := &priorityItem[J]{
+		job: job,
+		key: pp.key(priority, time.Now()),
+		seq: pp.seq,
+	}
+	heap.Push(&pp.queue, item)
+	pp.cond.Signal()
+
+	// 新任务比调度goroutine手上的任务优先时，让它放回去重新选
+	if pp.held != nil && item.key > pp.held.key {
+		select {
+		case pp.preempt <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// key 计算排序键
+//
+// 有效优先级 = priority + (now - enqueued) / aging，
+// 比较两个任务时 now 会被抵消，所以可以在入队时算出一个不变的键：
+// priority - (enqueued - start) / aging
+func (pp *PriorityPool[J, R]) key(priority int, enqueued time.Time) float64 {
+	if pp.aging <= 0 {
+		return float64(priority)
+	}
+	return float64(priority) - float64(enqueued.Sub(pp.start))/float64(pp.aging)
+}
+
+// dispatch 按优先级把任务交给内部工作池
+func (pp *PriorityPool[J, R]) dispatch() {
+	defer close(pp.done)
+
+	for {
+		pp.mu.Lock()
+		for pp.queue.Len() == 0 && !pp.closed && pp.ctx.Err() == nil {
+			pp.cond.Wait()
+		}
+
+		if pp.ctx.Err() != nil {
+			for pp.queue.Len() > 0 {
+				pp.skipped = append(pp.skipped, heap.Pop(&pp.queue).(*priorityItem[J]).job)
+			}
+			pp.mu.Unlock()
+			pp.pool.Close()
+			return
+		}
+		if pp.queue.Len() == 0 {
+			// 已关闭且队列为空
+			pp.mu.Unlock()
+			pp.pool.Close()
+			return
+		}
+
+		item := heap.Pop(&pp.queue).(*priorityItem[J])
+		pp.held = item
+		select {
+		case <-pp.preempt: // 丢弃上一轮留下的信号
+		default:
+		}
+		pp.mu.Unlock()
+
+		// 内部队列非缓冲，这里会等到有worker空闲或被更优先的任务抢占
+		err := pp.pool.submit(item.job, pp.preempt)
+
+		pp.mu.Lock()
+		pp.held = nil
+		switch {
+		case errors.Is(err, errPreempted):
+			heap.Push(&pp.queue, item)
+		case err != nil:
+			pp.skipped = append(pp.skipped, item.job)
+		}
+		pp.mu.Unlock()
+	}
+}
+
+// Results 返回结果Channel，所有任务完成后关闭
+func (pp *PriorityPool[J, R]) Results() <-chan Result[J, R] {
+	return pp.pool.Results()
+}
+
+// Close 停止接收新任务，队列中的任务仍按优先级处理完
+func (pp *PriorityPool[J, R]) Close() {
+	pp.mu.Lock()
+	pp.closed = true
+	pp.cond.Broadcast()
+	pp.mu.Unlock()
+}
+
+// Wait 阻塞直到调度结束且内部工作池的所有worker退出
+func (pp *PriorityPool[J, R]) Wait() {
+	<-pp.done
+	pp.pool.Wait()
+}
+
+// QueueLen 返回优先级队列中等待调度的任务数量
+func (pp *PriorityPool[J, R]) QueueLen() int {
+	pp.mu.Lock()
+	defer pp.mu.Unlock()
+	return pp.queue.Len()
+}
+
+// Skipped 返回因context取消而没有执行的任务，Wait 返回后结果才完整
+func (pp *PriorityPool[J, R]) Skipped() []J {
+	pp.mu.Lock()
+	skipped := append([]J(nil), pp.skipped...)
+	pp.mu.Unlock()
+	return append(skipped, pp.pool.Skipped()...)
+}
+
+// priorityItem 是优先级队列中的一项
+type priorityItem[J any] struct {
+	job J
+	key float64
+	seq uint64 // 相同优先级按提交顺序处理
+}
+
+// priorityQueue 实现 heap.Interface，键越大越先出队
+type priorityQueue[J any] []*priorityItem[J]
+
+func (q priorityQueue[J]) Len() int { return len(q) }
+
+func (q priorityQueue[J]) Less(i, j int) bool {
+	if q[i].key != q[j].key {
+		return q[i].key > q[j].key
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue[J]) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue[J]) Push(x any) { *q = append(*q, x.(*priorityItem[J])) }
+
+func (q *priorityQueue[J]) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return item
+}
@@ -0,0 +1,196 @@
+package workerpool
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// blockingJob 第一个任务阻塞worker，直到release关闭，便于在队列中堆积任务
+type blockingJob struct {
+	id      int
+	release chan struct{}
+}
+
+func runBlockingJob(_ context.Context, j blockingJob) (int, error) {
+	if j.release != nil {
+		<-j.release
+	}
+	return j.id, nil
+}
+
+// TestPriorityOrdering 测试高优先级任务先于低优先级任务执行
+func TestPriorityOrdering(t *testing.T) {
+	pp := NewPriority(context.Background(), 1, runBlockingJob, WithResultSize(100))
+
+	release := make(chan struct{})
+	pp.Submit(blockingJob{id: 0, release: release})
+	waitFor(t, "worker取走阻塞任务", func() bool { return pp.QueueLen() == 0 })
+
+	// worker被占用期间，按优先级混合提交
+	// 调度goroutine手上等待worker的任务会被后到的高优先级任务抢占
+	priorities := []int{1, 5, 3, 5, 1, 9, 3}
+	for i, prio := range priorities {
+		pp.SubmitPriority(blockingJob{id: i + 1}, prio)
+	}
+
+	close(release)
+	pp.Close()
+
+	var order []int
+	for r := range pp.Results() {
+		order = append(order, r.Value)
+	}
+	pp.Wait()
+
+	// 优先级从高到低，同优先级按提交顺序
+	want := []int{0, 6, 2, 4, 3, 7, 1, 5}
+	if len(order) != len(want) {
+		t.Fatalf("期望 %d 个结果, 实际 %d", len(want), len(order))
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("执行顺序 %v, 期望 %v", order, want)
+		}
+	}
+}
+
+// TestPriorityUnderLoad 测试持续负载下高优先级任务的平均位置靠前
+func TestPriorityUnderLoad(t *testing.T) {
+	pp := NewPriority(context.Background(), 2, func(_ context.Context, prio int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return prio, nil
+	}, WithResultSize(400))
+
+	for i := 0; i < 400; i++ {
+		pp.SubmitPriority(i%2, i%2) // 高低优先级交替提交
+	}
+	pp.Close()
+
+	var highPos, lowPos, high, low int
+	pos := 0
+	for r := range pp.Results() {
+		if r.Value == 1 {
+			highPos += pos
+			high++
+		} else {
+			lowPos += pos
+			low++
+		}
+		pos++
+	}
+	pp.Wait()
+
+	if high != 200 || low != 200 {
+		t.Fatalf("期望高低优先级各200个, 实际 %d/%d", high, low)
+	}
+	if highPos/high >= lowPos/low {
+		t.Errorf("高优先级平均位置 %d 应该小于低优先级 %d", highPos/high, lowPos/low)
+	}
+}
+
+// TestPriorityPreemptOrdered 测试有序模式下被抢占的任务不会让结果流卡住
+func TestPriorityPreemptOrdered(t *testing.T) {
+	pp := NewPriority(context.Background(), 1, runBlockingJob, WithOrdered(4), WithResultSize(10))
+
+	release := make(chan struct{})
+	pp.Submit(blockingJob{id: 0, release: release})
+	waitFor(t, "worker取走阻塞任务", func() bool { return pp.QueueLen() == 0 })
+
+	pp.SubmitPriority(blockingJob{id: 1}, 0)
+	waitFor(t, "调度goroutine取走任务1", func() bool { return pp.QueueLen() == 0 })
+	pp.SubmitPriority(blockingJob{id: 2}, 5) // 抢占任务1
+	pp.SubmitPriority(blockingJob{id: 3}, 9) // 再抢占任务2
+
+	close(release)
+	pp.Close()
+
+	var order []int
+	for r := range pp.Results() {
+		order = append(order, r.Value)
+	}
+	pp.Wait()
+
+	want := []int{0, 3, 2, 1}
+	if len(order) != len(want) {
+		t.Fatalf("执行顺序 %v, 期望 %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("执行顺序 %v, 期望 %v", order, want)
+		}
+	}
+}
+
+// TestPriorityOptionsNotModified 测试 NewPriority 不写入调用方的opts底层数组
+func TestPriorityOptionsNotModified(t *testing.T) {
+	opts := make([]Option, 1, 2)
+	opts[0] = WithResultSize(1)
+
+	pp := NewPriority(context.Background(), 1, runBlockingJob, opts...)
+	pp.Close()
+	for range pp.Results() {
+	}
+	pp.Wait()
+
+	if opts[:2][1] != nil {
+		t.Error("调用方opts的底层数组被改写")
+	}
+}
+
+// TestPriorityAging 测试老化让等待很久的低优先级任务超过新的高优先级任务
+func TestPriorityAging(t *testing.T) {
+	pp := NewPriority(context.Background(), 1, runBlockingJob,
+		WithAging(10*time.Millisecond), WithResultSize(10))
+
+	release := make(chan struct{})
+	pp.Submit(blockingJob{id: 0, release: release})
+	waitFor(t, "worker取走阻塞任务", func() bool { return pp.QueueLen() == 0 })
+
+	pp.SubmitPriority(blockingJob{id: 1}, 0) // 最早提交，老化后一直排在最前
+	time.Sleep(5 * time.Millisecond)
+	pp.SubmitPriority(blockingJob{id: 2}, 0) // 低优先级，但等待了很久
+	time.Sleep(100 * time.Millisecond)       // 约等于10个老化周期
+	pp.SubmitPriority(blockingJob{id: 3}, 5) // 新来的高优先级
+
+	close(release)
+	pp.Close()
+
+	var order []int
+	for r := range pp.Results() {
+		order = append(order, r.Value)
+	}
+	pp.Wait()
+
+	if len(order) != 4 || order[2] != 2 || order[3] != 3 {
+		t.Errorf("执行顺序 %v, 期望老化后的任务2排在任务3之前", order)
+	}
+}
+
+// TestPriorityCancel 测试取消后队列中的任务被记为跳过且goroutine退出
+func TestPriorityCancel(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pp := NewPriority(ctx, 1, runBlockingJob)
+
+	release := make(chan struct{})
+	pp.Submit(blockingJob{id: 0, release: release})
+	for i := 1; i <= 5; i++ {
+		pp.SubmitPriority(blockingJob{id: i}, i)
+	}
+	waitFor(t, "任务进入队列", func() bool { return pp.QueueLen() <= 5 })
+
+	cancel()
+	close(release)
+	pp.Wait()
+
+	if err := pp.Submit(blockingJob{id: 6}); err == nil {
+		t.Error("取消后 Submit 应该返回错误")
+	}
+	if n := len(pp.Skipped()); n < 4 {
+		t.Errorf("期望至少4个任务被跳过, 实际 %d", n)
+	}
+	checkGoroutineLeak(t, baseline)
+}
@@ -0,0 +1,179 @@
+package workerpool
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Size 返回当前的目标worker数量
+func (p *Pool[J, R]) Size() int {
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+	return len(p.stops)
+}
+
+// QueueLen 返回等待处理的任务数量：队列中的任务加上阻塞在 Submit 上的任务
+// 默认的非缓冲队列长度总是0，积压只体现在阻塞的 Submit 上
+func (p *Pool[J, R]) QueueLen() int {
+	return len(p.jobs) + int(p.blocked.Load())
+}
+
+// Latency 返回最近任务从提交到完成的平均耗时（指数加权移动平均）
+func (p *Pool[J, R]) Latency() time.Duration {
+	return p.latency.value()
+}
+
+// Finished 返回已经执行完的任务数（成功和失败都算），Autoscale 用它判断采样间隔内是否空闲
+func (p *Pool[J, R]) Finished() int64 {
+	return p.counters.completed.Load() + p.counters.failed.Load()
+}
+
+// Resize 把worker数量调整为n（n小于1时按1处理）
+//
+// 扩容立即启动新的worker；缩容不会打断正在执行的任务，
+// 被选中的worker处理完当前任务后自行退出。
+// 工作池关闭或取消后返回 ErrPoolClosed。
+func (p *Pool[J, R]) Resize(n int) error {
+	if n < 1 {
+		n = 1
+	}
+
+	p.sizeMu.Lock()
+	defer p.sizeMu.Unlock()
+
+	if p.exiting {
+		return ErrPoolClosed
+	}
+
+	for len(p.stops) < n {
+		p.startWorker()
+	}
+	for len(p.stops) > n {
+		last := len(p.stops) - 1
+		close(p.stops[last])
+		p.stops = p.stops[:last]
+	}
+	return nil
+}
+
+// latencyTracker 用指数加权移动平均记录任务延迟
+type latencyTracker struct {
+	mu   sync.Mutex
+	ewma float64
+	seen bool
+}
+
+// latencyAlpha 新样本的权重
+const latencyAlpha = 0.2
+
+func (l *latencyTracker) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.seen {
+		l.ewma = float64(d)
+		l.seen = true
+		return
+	}
+	l.ewma = latencyAlpha*float64(d) + (1-latencyAlpha)*l.ewma
+}
+
+func (l *latencyTracker) value() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Duration(l.ewma)
+}
+
+// Scalable 是可以被 Autoscale 调整大小的工作池
+type Scalable interface {
+	Size() int
+	Resize(n int) error
+	QueueLen() int
+	Latency() time.Duration
+	Finished() int64
+}
+
+// AutoscaleConfig 自动伸缩的参数
+type AutoscaleConfig struct {
+	Min      int           // 最少worker数量，小于1时按1处理
+	Max      int           // 最多worker数量，小于Min时按Min处理
+	Interval time.Duration // 采样间隔，默认1秒
+	// TargetLatency 期望的任务延迟（含排队时间）
+	// 有积压且延迟超过该值时扩容，队列为空且延迟低于一半时缩容
+	TargetLatency time.Duration
+	// Step 每次扩容增加的worker数量，默认1
+	Step int
+}
+
+func (c AutoscaleConfig) normalize() AutoscaleConfig {
+	if c.Min < 1 {
+		c.Min = 1
+	}
+	if c.Max < c.Min {
+		c.Max = c.Min
+	}
+	if c.Interval <= 0 {
+		c.Interval = time.Second
+	}
+	if c.Step < 1 {
+		c.Step = 1
+	}
+	return c
+}
+
+// decide 根据一次采样计算新的worker数量
+func (c AutoscaleConfig) decide(size, queueLen int, latency time.Duration) int {
+	next := size
+	switch {
+	case queueLen > 0 && latency > c.TargetLatency:
+		next = size + c.Step
+	case queueLen == 0 && latency < c.TargetLatency/2:
+		next = size - 1
+	}
+
+	if next < c.Min {
+		next = c.Min
+	}
+	if next > c.Max {
+		next = c.Max
+	}
+	return next
+}
+
+// Autoscale 周期性地根据队列深度和延迟调整工作池大小，直到ctx取消
+// 通常在单独的goroutine中运行：go workerpool.Autoscale(ctx, pool, cfg)
+func Autoscale(ctx context.Context, p Scalable, cfg AutoscaleConfig) {
+	cfg = cfg.normalize()
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+
+	finished := p.Finished()
+	idleTicks := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// 延迟只在任务完成时更新，突发结束后会一直停在高位。
+			// 采样间隔内没有任务完成时，相当于给平均值加入一个0样本，让它逐次衰减
+			if n := p.Finished(); n != finished {
+				finished = n
+				idleTicks = 0
+			} else {
+				idleTicks++
+			}
+			latency := time.Duration(float64(p.Latency()) * math.Pow(1-latencyAlpha, float64(idleTicks)))
+
+			size := p.Size()
+			next := cfg.decide(size, p.QueueLen(), latency)
+			if next != size {
+				if err := p.Resize(next); err != nil {
+					return // 工作池已关闭
+				}
+			}
+		}
+	}
+}
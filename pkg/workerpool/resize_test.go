@@ -0,0 +1,239 @@
+package workerpool
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// trackRunning 返回一个记录当前并发数和最大并发数的任务函数
+func trackRunning(running, maxRunning *int64, release <-chan struct{}) func(context.Context, int) (int, error) {
+	return func(_ context.Context, n int) (int, error) {
+		cur := atomic.AddInt64(running, 1)
+		for {
+			old := atomic.LoadInt64(maxRunning)
+			if cur <= old || atomic.CompareAndSwapInt64(maxRunning, old, cur) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt64(running, -1)
+		return n, nil
+	}
+}
+
+// waitFor 轮询直到条件成立或超时
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("等待超时: %s", what)
+}
+
+// TestResizeGrow 测试扩容后并发数增加
+func TestResizeGrow(t *testing.T) {
+	var running, maxRunning int64
+	release := make(chan struct{})
+
+	p := New(context.Background(), 2, trackRunning(&running, &maxRunning, release),
+		WithQueueSize(20), WithResultSize(20))
+
+	for i := 0; i < 10; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "2个worker开始工作", func() bool { return atomic.LoadInt64(&running) == 2 })
+
+	if err := p.Resize(6); err != nil {
+		t.Fatalf("Resize 返回错误: %v", err)
+	}
+	if p.Size() != 6 {
+		t.Errorf("Size() = %d, 期望 6", p.Size())
+	}
+	waitFor(t, "6个worker开始工作", func() bool { return atomic.LoadInt64(&running) == 6 })
+
+	close(release)
+	p.Close()
+	p.Wait()
+
+	if len(p.Results()) != 10 {
+		t.Errorf("期望10个结果, 实际 %d", len(p.Results()))
+	}
+}
+
+// TestResizeShrinkFinishesCurrentJob 测试缩容时worker完成当前任务后才退出
+func TestResizeShrinkFinishesCurrentJob(t *testing.T) {
+	var running, maxRunning int64
+	release := make(chan struct{})
+
+	p := New(context.Background(), 4, trackRunning(&running, &maxRunning, release),
+		WithQueueSize(20), WithResultSize(20))
+
+	for i := 0; i < 4; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "4个worker开始工作", func() bool { return atomic.LoadInt64(&running) == 4 })
+
+	p.Resize(1)
+	if p.Size() != 1 {
+		t.Errorf("Size() = %d, 期望 1", p.Size())
+	}
+
+	// 正在执行的4个任务都应该正常完成
+	for i := 0; i < 4; i++ {
+		release <- struct{}{}
+	}
+	for i := 0; i < 4; i++ {
+		if r := <-p.Results(); r.Err != nil {
+			t.Errorf("缩容期间的任务失败: %v", r.Err)
+		}
+	}
+
+	// 之后最多只有1个worker在工作
+	atomic.StoreInt64(&maxRunning, 0)
+	for i := 0; i < 6; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "1个worker开始工作", func() bool { return atomic.LoadInt64(&running) == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if m := atomic.LoadInt64(&maxRunning); m != 1 {
+		t.Errorf("缩容后最大并发数 %d, 期望 1", m)
+	}
+
+	close(release)
+	p.Close()
+	p.Wait()
+}
+
+// TestResizeAfterClose 测试关闭后 Resize 返回 ErrPoolClosed
+func TestResizeAfterClose(t *testing.T) {
+	p := New(context.Background(), 2, func(_ context.Context, n int) (int, error) { return n, nil })
+	p.Close()
+	p.Wait()
+
+	if err := p.Resize(5); err != ErrPoolClosed {
+		t.Errorf("期望 ErrPoolClosed, 实际 %v", err)
+	}
+}
+
+// TestAutoscaleDecide 测试自动伸缩的决策规则
+func TestAutoscaleDecide(t *testing.T) {
+	cfg := AutoscaleConfig{Min: 2, Max: 8, TargetLatency: 100 * time.Millisecond, Step: 2}.normalize()
+
+	tests := []struct {
+		name     string
+		size     int
+		queueLen int
+		latency  time.Duration
+		want     int
+	}{
+		{"积压且延迟高时扩容", 4, 10, 200 * time.Millisecond, 6},
+		{"扩容不超过上限", 7, 10, 200 * time.Millisecond, 8},
+		{"积压但延迟正常时保持", 4, 10, 80 * time.Millisecond, 4},
+		{"空闲且延迟低时缩容", 4, 0, 10 * time.Millisecond, 3},
+		{"缩容不低于下限", 2, 0, 10 * time.Millisecond, 2},
+		{"空闲但延迟不算低时保持", 4, 0, 70 * time.Millisecond, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cfg.decide(tt.size, tt.queueLen, tt.latency); got != tt.want {
+				t.Errorf("decide(%d, %d, %v) = %d, 期望 %d", tt.size, tt.queueLen, tt.latency, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAutoscaleGrowsUnderLoad 测试有积压时自动扩容
+func TestAutoscaleGrowsUnderLoad(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := New(ctx, 1, func(_ context.Context, n int) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return n, nil
+	}, WithQueueSize(100), WithResultSize(100))
+
+	go Autoscale(ctx, p, AutoscaleConfig{
+		Min:           1,
+		Max:           4,
+		Interval:      10 * time.Millisecond,
+		TargetLatency: 5 * time.Millisecond,
+	})
+
+	for i := 0; i < 60; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "扩容到4个worker", func() bool { return p.Size() == 4 })
+
+	p.Close()
+	p.Wait()
+}
+
+// TestAutoscaleGrowsUnbuffered 测试默认的非缓冲队列下，阻塞的 Submit 也算积压
+func TestAutoscaleGrowsUnbuffered(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := New(ctx, 1, func(_ context.Context, n int) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return n, nil
+	}, WithResultSize(100))
+
+	go Autoscale(ctx, p, AutoscaleConfig{
+		Min:           1,
+		Max:           4,
+		Interval:      10 * time.Millisecond,
+		TargetLatency: 5 * time.Millisecond,
+	})
+
+	submitted := make(chan struct{})
+	go func() {
+		defer close(submitted)
+		for i := 0; i < 60; i++ {
+			p.Submit(i)
+		}
+	}()
+	waitFor(t, "扩容到4个worker", func() bool { return p.Size() == 4 })
+
+	<-submitted
+	p.Close()
+	p.Wait()
+}
+
+// TestAutoscaleShrinksWhenIdle 测试突发结束后没有任务完成时延迟衰减，工作池缩回下限
+func TestAutoscaleShrinksWhenIdle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := New(ctx, 4, func(_ context.Context, n int) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return n, nil
+	}, WithQueueSize(100), WithResultSize(100))
+
+	// 先制造一次突发，让平均延迟远高于目标
+	for i := 0; i < 20; i++ {
+		p.Submit(i)
+	}
+	for i := 0; i < 20; i++ {
+		<-p.Results()
+	}
+	if p.Latency() < 5*time.Millisecond {
+		t.Fatalf("突发后延迟 %v, 期望高于目标", p.Latency())
+	}
+
+	go Autoscale(ctx, p, AutoscaleConfig{
+		Min:           1,
+		Max:           4,
+		Interval:      10 * time.Millisecond,
+		TargetLatency: 5 * time.Millisecond,
+	})
+	waitFor(t, "空闲后缩容到1个worker", func() bool { return p.Size() == 1 })
+
+	p.Close()
+	p.Wait()
+}
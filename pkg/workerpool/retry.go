@@ -0,0 +1,62 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Sakuya1998/go-learning-path/internal/retry"
+)
+
+// RetryPolicy 描述任务失败后的重试策略，与 pipeline.RetryPolicy 是同一个类型
+//
+// 字段含义见 retry.Policy：MaxAttempts、InitialBackoff、MaxBackoff、Multiplier、
+// Jitter 和 Retryable。panic不会被重试。
+type RetryPolicy = retry.Policy
+
+// DefaultRetryPolicy 返回一个常用的重试策略：最多3次，100ms起步指数退避
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetry 为工作池设置重试策略，默认不重试
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
+
+// shouldRetry 判断err是否可以重试，panic通常是程序错误，不会重试
+func shouldRetry(rp RetryPolicy, err error) bool {
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		return false
+	}
+	return rp.ShouldRetry(err)
+}
+
+// runWithRetry 按重试策略执行任务，返回最终的值、执行次数和错误
+func runWithRetry[J, R any](ctx context.Context, rp RetryPolicy, fn func(context.Context, J) (R, error), job J) (R, int, error) {
+	var (
+		value R
+		err   error
+	)
+
+	maxAttempts := rp.Attempts()
+	for attempt := 1; ; attempt++ {
+		value, err = safeCall(ctx, fn, job)
+		if err == nil || attempt >= maxAttempts || !shouldRetry(rp, err) {
+			return value, attempt, err
+		}
+
+		if !retry.Sleep(ctx, rp.Backoff(attempt)) {
+			return value, attempt, err
+		}
+	}
+}
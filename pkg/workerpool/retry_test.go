@@ -0,0 +1,108 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errTemporary = errors.New("临时错误")
+var errFatal = errors.New("致命错误")
+
+// TestRetryUntilSuccess 测试失败的任务被重试直到成功
+func TestRetryUntilSuccess(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[int]int)
+
+	p := New(context.Background(), 3, func(_ context.Context, n int) (int, error) {
+		mu.Lock()
+		calls[n]++
+		c := calls[n]
+		mu.Unlock()
+
+		// 每个任务前两次失败，第三次成功
+		if c < 3 {
+			return 0, errTemporary
+		}
+		return n * 10, nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Millisecond, Jitter: 0.5}))
+
+	go func() {
+		for i := 1; i <= 10; i++ {
+			p.Submit(i)
+		}
+		p.Close()
+	}()
+
+	for r := range p.Results() {
+		if r.Err != nil {
+			t.Errorf("Job %d 期望成功, 实际错误: %v", r.Job, r.Err)
+		}
+		if r.Attempts != 3 {
+			t.Errorf("Job %d 期望执行3次, 实际 %d", r.Job, r.Attempts)
+		}
+		if r.Value != r.Job*10 {
+			t.Errorf("Job %d 结果错误: %d", r.Job, r.Value)
+		}
+	}
+}
+
+// TestRetryGivesUp 测试达到最大次数后返回最后一次错误
+func TestRetryGivesUp(t *testing.T) {
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) {
+		return 0, errTemporary
+	}, WithRetry(RetryPolicy{MaxAttempts: 4}))
+
+	p.Submit(1)
+	p.Close()
+
+	r := <-p.Results()
+	if !errors.Is(r.Err, errTemporary) {
+		t.Errorf("期望 errTemporary, 实际 %v", r.Err)
+	}
+	if r.Attempts != 4 {
+		t.Errorf("期望执行4次, 实际 %d", r.Attempts)
+	}
+	p.Wait()
+}
+
+// TestRetryablePredicate 测试不可重试的错误立即返回
+func TestRetryablePredicate(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 5,
+		Retryable:   func(err error) bool { return !errors.Is(err, errFatal) },
+	}
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) {
+		return 0, errFatal
+	}, WithRetry(policy))
+
+	p.Submit(1)
+	p.Close()
+
+	r := <-p.Results()
+	if r.Attempts != 1 {
+		t.Errorf("不可重试错误期望执行1次, 实际 %d", r.Attempts)
+	}
+	p.Wait()
+}
+
+// TestRetryBackoffCancelled 测试等待退避时取消会立即返回
+func TestRetryBackoffCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := New(ctx, 1, func(_ context.Context, n int) (int, error) {
+		return 0, errTemporary
+	}, WithRetry(RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour}), WithResultSize(1))
+
+	p.Submit(1)
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	cancel()
+	p.Wait()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("取消后等待时间过长: %v", elapsed)
+	}
+}
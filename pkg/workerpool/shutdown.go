@@ -0,0 +1,97 @@
+package workerpool
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Report 是 Shutdown 的结果
+type Report[J any] struct {
+	Completed int64 // 正常执行结束的任务数（包括返回错误的任务）
+	Dropped   []J   // 还在队列中、没有开始执行的任务
+	Abandoned []J   // 执行到一半被取消的任务
+	Forced    bool  // 是否因为期限到达而强制取消
+}
+
+// Shutdown 优雅关闭工作池
+//
+// 流程与练习6相同：
+//  1. 停止接收新任务（等同于 Close）
+//  2. 继续处理队列中的任务，直到全部完成或ctx到期
+//  3. ctx到期后取消工作池的context，正在执行的任务收到取消信号，
+//     队列中剩余的任务不再执行
+//
+// 关闭期间调用方仍需读取 Results。强制取消时返回ctx.Err()。
+func (p *Pool[J, R]) Shutdown(ctx context.Context) (Report[J], error) {
+	// Close 要等阻塞的Submit离开才关闭队列，放到期限之内进行
+	go p.Close()
+
+	var err error
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		p.cancel()
+		<-p.done
+	}
+
+	p.mu.RLock()
+	abandoned := append([]J(nil), p.abandoned...)
+	p.mu.RUnlock()
+
+	return Report[J]{
+		Completed: p.counters.completed.Load() + p.counters.failed.Load(),
+		Dropped:   p.Skipped(),
+		Abandoned: abandoned,
+		Forced:    err != nil,
+	}, err
+}
+
+// Done 返回一个在所有worker退出、Results 关闭后关闭的channel
+func (p *Pool[J, R]) Done() <-chan struct{} {
+	return p.done
+}
+
+// ShutdownOnSignal 在收到信号时以 timeout 为期限调用 p.Shutdown，
+// 关闭完成后把报告发送到返回的channel。未指定信号时监听 SIGINT 和 SIGTERM。
+//
+// 收到第一个信号后会恢复信号的默认处理，命令行工具中再按一次Ctrl+C即可强制退出。
+// 工作池正常结束时监听goroutine也会退出，返回的channel随之关闭。
+//
+//	reports := workerpool.ShutdownOnSignal(pool, 5*time.Second)
+//	... 提交任务、读取结果 ...
+//	if r, ok := <-reports; ok {
+//		fmt.Printf("已完成 %d, 丢弃 %d, 放弃 %d\n", r.Completed, len(r.Dropped), len(r.Abandoned))
+//	}
+func ShutdownOnSignal[J, R any](p *Pool[J, R], timeout time.Duration, sigs ...os.Signal) <-chan Report[J] {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, sigs...)
+
+	reports := make(chan Report[J], 1)
+	go func() {
+		defer close(reports)
+		defer signal.Stop(sigCh)
+
+		select {
+		case <-sigCh:
+		case <-p.Done():
+			return
+		}
+
+		signal.Stop(sigCh)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		report, _ := p.Shutdown(ctx)
+		reports <- report
+	}()
+
+	return reports
+}
@@ -0,0 +1,106 @@
+package workerpool
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestShutdownDrainsQueue 测试期限内队列中的任务全部处理完
+func TestShutdownDrainsQueue(t *testing.T) {
+	p := New(context.Background(), 2, func(_ context.Context, n int) (int, error) {
+		time.Sleep(time.Millisecond)
+		return n, nil
+	}, WithQueueSize(20), WithResultSize(20))
+
+	for i := 0; i < 20; i++ {
+		p.Submit(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	report, err := p.Shutdown(ctx)
+	if err != nil {
+		t.Fatalf("Shutdown 返回错误: %v", err)
+	}
+	if report.Completed != 20 || len(report.Dropped) != 0 || len(report.Abandoned) != 0 || report.Forced {
+		t.Errorf("报告 = %+v, 期望全部完成", report)
+	}
+	if err := p.Submit(99); err != ErrPoolClosed {
+		t.Errorf("关闭后 Submit 期望 ErrPoolClosed, 实际 %v", err)
+	}
+}
+
+// TestShutdownBlockedSubmitter 测试有Submit阻塞时期限仍然生效，阻塞的Submit返回 ErrPoolClosed
+func TestShutdownBlockedSubmitter(t *testing.T) {
+	p := New(context.Background(), 1, func(ctx context.Context, n int) (int, error) {
+		select {
+		case <-time.After(3 * time.Second):
+		case <-ctx.Done():
+		}
+		return n, ctx.Err()
+	}, WithResultSize(10))
+
+	p.Submit(0)
+	waitFor(t, "worker取走第一个任务", func() bool { return p.Stats().InFlight == 1 })
+
+	submitted := make(chan error, 1)
+	go func() { submitted <- p.Submit(1) }() // 唯一的worker在忙，非缓冲队列上阻塞
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := p.Shutdown(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown 用了 %v, 期望在期限200ms附近返回", elapsed)
+	}
+	if err != context.DeadlineExceeded {
+		t.Errorf("期望 DeadlineExceeded, 实际 %v", err)
+	}
+	if err := <-submitted; err != ErrPoolClosed {
+		t.Errorf("阻塞的 Submit 期望 ErrPoolClosed, 实际 %v", err)
+	}
+}
+
+// TestShutdownDeadline 测试期限到达后取消正在执行的任务并丢弃队列
+func TestShutdownDeadline(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	p := New(context.Background(), 2, func(ctx context.Context, n int) (int, error) {
+		if n < 2 {
+			return n, nil // 前两个任务很快完成
+		}
+		<-ctx.Done() // 其余任务只有被取消才会结束
+		return 0, ctx.Err()
+	}, WithQueueSize(10), WithResultSize(10))
+
+	for i := 0; i < 10; i++ {
+		p.Submit(i)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	report, err := p.Shutdown(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("期望 DeadlineExceeded, 实际 %v", err)
+	}
+	if !report.Forced {
+		t.Error("期望 Forced 为 true")
+	}
+	if report.Completed != 2 {
+		t.Errorf("Completed = %d, 期望 2", report.Completed)
+	}
+	if len(report.Abandoned) != 2 {
+		t.Errorf("Abandoned = %v, 期望2个正在执行的任务", report.Abandoned)
+	}
+	if got := report.Completed + int64(len(report.Dropped)+len(report.Abandoned)); got != 10 {
+		t.Errorf("完成+丢弃+放弃 = %d, 期望 10", got)
+	}
+
+	checkGoroutineLeak(t, baseline)
+}
@@ -0,0 +1,55 @@
+//go:build unix
+
+package workerpool
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestShutdownOnSignal 测试收到信号后触发关闭
+func TestShutdownOnSignal(t *testing.T) {
+	release := make(chan struct{})
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}, WithQueueSize(5), WithResultSize(5))
+
+	reports := ShutdownOnSignal(p, time.Second, syscall.SIGUSR1)
+	for i := 0; i < 3; i++ {
+		p.Submit(i)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	select {
+	case r := <-reports:
+		if r.Completed != 3 || r.Forced {
+			t.Errorf("报告 = %+v, 期望3个任务正常完成", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("收到信号后没有完成关闭")
+	}
+}
+
+// TestShutdownOnSignalPoolFinished 测试工作池自行结束时监听goroutine退出
+func TestShutdownOnSignalPoolFinished(t *testing.T) {
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) { return n, nil })
+	reports := ShutdownOnSignal(p, time.Second, syscall.SIGUSR2)
+
+	p.Close()
+	p.Wait()
+
+	select {
+	case _, ok := <-reports:
+		if ok {
+			t.Error("没有收到信号时不应该有报告")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("工作池结束后报告channel没有关闭")
+	}
+}
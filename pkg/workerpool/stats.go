@@ -0,0 +1,155 @@
+package workerpool
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats 是工作池某一时刻的统计快照
+type Stats struct {
+	Workers   int   // 当前worker数量
+	Submitted int64 // 已提交的任务数
+	Completed int64 // 成功完成的任务数
+	Failed    int64 // 最终失败的任务数（重试后仍然失败）
+	InFlight  int64 // 正在执行的任务数
+	QueueLen  int   // 等待的任务数，包括阻塞在 Submit 上的
+
+	// 任务执行耗时（含重试）的分位数，根据直方图估算
+	P50 time.Duration
+	P95 time.Duration
+	P99 time.Duration
+
+	Duration HistogramSnapshot // 执行耗时直方图
+}
+
+// counters 是工作池内部的计数器
+type counters struct {
+	submitted atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	inFlight  atomic.Int64
+	duration  histogram
+}
+
+// Stats 返回当前的统计快照
+func (p *Pool[J, R]) Stats() Stats {
+	h := p.counters.duration.snapshot()
+	return Stats{
+		Workers:   p.Size(),
+		Submitted: p.counters.submitted.Load(),
+		Completed: p.counters.completed.Load(),
+		Failed:    p.counters.failed.Load(),
+		InFlight:  p.counters.inFlight.Load(),
+		QueueLen:  p.QueueLen(),
+		P50:       h.Quantile(0.50),
+		P95:       h.Quantile(0.95),
+		P99:       h.Quantile(0.99),
+		Duration:  h,
+	}
+}
+
+// Stats 返回当前的统计快照，QueueLen 为优先级队列中等待调度的任务数
+func (pp *PriorityPool[J, R]) Stats() Stats {
+	s := pp.pool.Stats()
+	// 任务在进入优先级队列时就算作已提交
+	pp.mu.Lock()
+	s.Submitted = int64(pp.seq)
+	s.QueueLen = pp.queue.Len()
+	pp.mu.Unlock()
+	return s
+}
+
+// DefaultDurationBuckets 是执行耗时直方图的默认桶上界，与Prometheus客户端的默认值接近
+var DefaultDurationBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// histogram 是固定桶的耗时直方图
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64 // 最后一个桶对应 +Inf
+	sum    time.Duration
+	count  uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.counts == nil {
+		h.counts = make([]uint64, len(DefaultDurationBuckets)+1)
+	}
+
+	i := 0
+	for i < len(DefaultDurationBuckets) && d > DefaultDurationBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.sum += d
+	h.count++
+}
+
+func (h *histogram) snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts := make([]uint64, len(DefaultDurationBuckets)+1)
+	copy(counts, h.counts)
+	return HistogramSnapshot{
+		Bounds: DefaultDurationBuckets,
+		Counts: counts,
+		Sum:    h.sum,
+		Count:  h.count,
+	}
+}
+
+// HistogramSnapshot 是直方图的快照
+type HistogramSnapshot struct {
+	Bounds []time.Duration // 各个桶的上界
+	Counts []uint64        // 各个桶的计数（非累计），比Bounds多一个+Inf桶
+	Sum    time.Duration   // 所有样本的总耗时
+	Count  uint64          // 样本总数
+}
+
+// Quantile 估算分位数q（0到1之间），在命中的桶内做线性插值
+// 落在+Inf桶时返回最大的有限上界
+func (s HistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := q * float64(s.Count)
+	var cumulative float64
+	for i, c := range s.Counts {
+		if c == 0 {
+			continue
+		}
+		if cumulative+float64(c) >= rank {
+			if i >= len(s.Bounds) {
+				return s.Bounds[len(s.Bounds)-1]
+			}
+			var lower time.Duration
+			if i > 0 {
+				lower = s.Bounds[i-1]
+			}
+			upper := s.Bounds[i]
+			frac := (rank - cumulative) / float64(c)
+			return lower + time.Duration(frac*float64(upper-lower))
+		}
+		cumulative += float64(c)
+	}
+	return s.Bounds[len(s.Bounds)-1]
+}
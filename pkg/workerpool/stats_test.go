@@ -0,0 +1,145 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStatsCounters 测试提交、成功、失败计数
+func TestStatsCounters(t *testing.T) {
+	p := New(context.Background(), 3, func(_ context.Context, n int) (int, error) {
+		if n%4 == 0 {
+			return 0, errors.New("失败")
+		}
+		return n, nil
+	}, WithQueueSize(20), WithResultSize(20))
+
+	for i := 1; i <= 20; i++ {
+		p.Submit(i)
+	}
+	p.Close()
+	p.Wait()
+
+	s := p.Stats()
+	if s.Submitted != 20 || s.Completed != 15 || s.Failed != 5 {
+		t.Errorf("Submitted/Completed/Failed = %d/%d/%d, 期望 20/15/5", s.Submitted, s.Completed, s.Failed)
+	}
+	if s.InFlight != 0 || s.QueueLen != 0 {
+		t.Errorf("结束后 InFlight=%d QueueLen=%d, 期望都为0", s.InFlight, s.QueueLen)
+	}
+	if s.Duration.Count != 20 {
+		t.Errorf("直方图样本数 %d, 期望 20", s.Duration.Count)
+	}
+}
+
+// TestStatsInFlightAndQueue 测试运行中的任务数和队列长度
+func TestStatsInFlightAndQueue(t *testing.T) {
+	release := make(chan struct{})
+	p := New(context.Background(), 2, func(_ context.Context, n int) (int, error) {
+		<-release
+		return n, nil
+	}, WithQueueSize(10), WithResultSize(10))
+
+	for i := 0; i < 7; i++ {
+		p.Submit(i)
+	}
+	waitFor(t, "2个任务在执行", func() bool { return p.Stats().InFlight == 2 })
+
+	if s := p.Stats(); s.QueueLen != 5 || s.Workers != 2 {
+		t.Errorf("QueueLen=%d Workers=%d, 期望 5 和 2", s.QueueLen, s.Workers)
+	}
+
+	close(release)
+	p.Close()
+	p.Wait()
+}
+
+// TestHistogramQuantile 测试分位数估算
+func TestHistogramQuantile(t *testing.T) {
+	var h histogram
+	// 90个样本落在(5ms,10ms]，10个样本落在(50ms,100ms]
+	for i := 0; i < 90; i++ {
+		h.observe(8 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		h.observe(80 * time.Millisecond)
+	}
+	s := h.snapshot()
+
+	if p50 := s.Quantile(0.5); p50 <= 5*time.Millisecond || p50 > 10*time.Millisecond {
+		t.Errorf("P50 = %v, 期望在 (5ms, 10ms]", p50)
+	}
+	if p99 := s.Quantile(0.99); p99 <= 50*time.Millisecond || p99 > 100*time.Millisecond {
+		t.Errorf("P99 = %v, 期望在 (50ms, 100ms]", p99)
+	}
+	if got := (HistogramSnapshot{}).Quantile(0.5); got != 0 {
+		t.Errorf("空直方图的分位数 = %v, 期望 0", got)
+	}
+}
+
+// TestExporterPrometheusFormat 测试Prometheus文本格式输出
+func TestExporterPrometheusFormat(t *testing.T) {
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) { return n, nil },
+		WithQueueSize(5), WithResultSize(5))
+	for i := 0; i < 5; i++ {
+		p.Submit(i)
+	}
+	p.Close()
+	p.Wait()
+
+	exp := NewExporter()
+	exp.Register("demo", p)
+
+	srv := httptest.NewServer(exp)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	text := string(body)
+
+	for _, want := range []string{
+		"# TYPE workerpool_jobs_submitted_total counter",
+		`workerpool_jobs_submitted_total{pool="demo"} 5`,
+		`workerpool_jobs_completed_total{pool="demo"} 5`,
+		`workerpool_jobs_failed_total{pool="demo"} 0`,
+		`workerpool_workers{pool="demo"} 1`,
+		"# TYPE workerpool_job_duration_seconds histogram",
+		`workerpool_job_duration_seconds_bucket{pool="demo",le="+Inf"} 5`,
+		`workerpool_job_duration_seconds_count{pool="demo"} 5`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("输出缺少 %q\n%s", want, text)
+		}
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+}
+
+// TestExporterLabelEscaping 测试标签值按Prometheus文本格式转义
+func TestExporterLabelEscaping(t *testing.T) {
+	p := New(context.Background(), 1, func(_ context.Context, n int) (int, error) { return n, nil })
+	p.Close()
+	p.Wait()
+
+	exp := NewExporter()
+	exp.Register("图片\t\"a\\b\"\n第二行", p)
+
+	var sb strings.Builder
+	if _, err := exp.WriteTo(&sb); err != nil {
+		t.Fatal(err)
+	}
+	want := `workerpool_workers{pool="图片` + "\t" + `\"a\\b\"\n第二行"} 1`
+	if !strings.Contains(sb.String(), want) {
+		t.Errorf("输出缺少 %q\n%s", want, sb.String())
+	}
+}